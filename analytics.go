@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Season analytics
+
+ The analytics sub-command summarizes the season's swap activity from the
+ tracker for the association's year-end review: swaps requested and completed
+ by division, the average notice given (days from the first search to the
+ game) and the most common decline reasons, i.e.
+
+   go-scheduler analytics
+*/
+
+// Structure to hold the swap activity for a division
+type activity_t struct {
+	requested  int
+	completed  int
+	noticed    int // searches with a notice, before the game
+	noticeDays int // total days of notice, for the average
+}
+
+/*
+Return a swap as completed if a match was accepted with -accept or marked
+Accepted in the report.
+*/
+func (tracker *tracker_t) completed(gameId string) bool {
+	if _, ok := tracker.Accepted[gameId]; ok {
+		return true
+	}
+	for _, response := range tracker.Responses[gameId] {
+		if strings.EqualFold(response, "accepted") {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Summarize the swap activity in the tracker by division. Games that aren't in
+the schedule are counted under "unknown".
+*/
+func swapActivity(tracker *tracker_t, games [][]string) map[string]*activity_t {
+	byId := make(map[string][]string)
+	for _, game := range games {
+		byId[game[schedule.GAMEID]] = game
+	}
+
+	activity := make(map[string]*activity_t)
+	for gameId, searched := range tracker.Searches {
+		division, date := "unknown", ""
+		if game, ok := byId[gameId]; ok {
+			division, date = game[schedule.DIVISION], game[schedule.DATE]
+		}
+		if activity[division] == nil {
+			activity[division] = &activity_t{}
+		}
+		a := activity[division]
+		a.requested++
+		if tracker.completed(gameId) {
+			a.completed++
+		}
+		// Only searches before a game with a date give notice, the days are
+		// counted in the association's location
+		location := schedule.Location()
+		d, err := time.ParseInLocation(schedule.DATE_FORMAT, date, location)
+		if err != nil {
+			continue
+		}
+		searched = searched.In(location)
+		day := time.Date(searched.Year(), searched.Month(), searched.Day(), 0, 0, 0, 0, location)
+		if notice := int(math.Round(d.Sub(day).Hours() / 24)); notice >= 0 {
+			a.noticed++
+			a.noticeDays += notice
+		}
+	}
+	return activity
+}
+
+/*
+Print the season's swap activity.
+
+	analytics [-tracker file] [-source plugin] [-plugins dir]
+*/
+func analyticsCommand(args []string) error {
+	flags := flag.NewFlagSet("analytics", flag.ExitOnError)
+	trackerFile := flags.String("tracker", "tracker.json", "File the swap tracker is stored in")
+	source := flags.String("source", "",
+		"Name of the plugin to get the schedule from instead of TTM")
+	pluginDir := flags.String("plugins", "plugins", "Directory containing data source plugins")
+	orgFlags(flags)
+	flags.Parse(args)
+
+	tracker, err := loadTracker(*trackerFile)
+	if err != nil {
+		return err
+	}
+	scheduleRecords, err := loadSchedule(*source, *pluginDir)
+	if err != nil {
+		return err
+	}
+	games := schedule.Rows(scheduleRecords, nil)
+
+	activity := swapActivity(tracker, games)
+	fmt.Printf("  %-20s %9s %9s %14s\n", "Division", "Requested", "Completed", "Average notice")
+	for _, division := range slices.Sorted(maps.Keys(activity)) {
+		a := activity[division]
+		if a.noticed == 0 {
+			fmt.Printf("  %-20s %9d %9d %14s\n", division, a.requested, a.completed, "-")
+			continue
+		}
+		fmt.Printf("  %-20s %9d %9d %9.1f days\n", division, a.requested, a.completed,
+			float64(a.noticeDays)/float64(a.noticed))
+	}
+
+	reasons := make(map[string]int)
+	for _, decline := range tracker.Declines {
+		reasons[decline.Reason]++
+	}
+	if len(reasons) > 0 {
+		fmt.Println()
+		fmt.Printf("  %-20s %9s\n", "Decline reason", "Declines")
+		byCount := slices.SortedFunc(maps.Keys(reasons), func(a, b string) int {
+			if reasons[a] != reasons[b] {
+				return reasons[b] - reasons[a]
+			}
+			return strings.Compare(a, b)
+		})
+		for _, reason := range byCount {
+			fmt.Printf("  %-20s %9d\n", reason, reasons[reason])
+		}
+	}
+	return nil
+}
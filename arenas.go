@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Arena locations
+
+ The schedule only names the arenas, so their locations are given in a JSON
+ file (arenas.json by default) of latitude and longitude by arena name:
+
+	{"Centennial Arena": {"lat": 43.6532, "lon": -79.3832},
+	 "Kinsmen Arena":    {"lat": 43.9001, "lon": -78.8610}}
+
+ The distance from the team's home arena, by name or as LAT,LON, to each
+ arena is measured in a straight line, which is close enough to rule out the
+ rinks that are too far to travel to.
+*/
+
+// File the arena locations are read from
+const ARENAS_FILE = "arenas.json"
+
+// Mean radius of the earth in kilometres
+const EARTH_KM = 6371.0
+
+// Structure to hold the location of an arena
+type arena_t struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+/*
+Read the arena locations from a file, by arena name in lower case. No
+locations are returned if the file doesn't exist.
+*/
+func readArenas(path string) (map[string]arena_t, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var named map[string]arena_t
+	if err := json.Unmarshal(data, &named); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	arenas := make(map[string]arena_t)
+	for name, arena := range named {
+		arenas[strings.ToLower(strings.TrimSpace(name))] = arena
+	}
+	return arenas, nil
+}
+
+/*
+Return the distance between two locations in kilometres.
+*/
+func distanceKm(a, b arena_t) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(b.Lat - a.Lat)
+	dLon := rad(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(a.Lat))*math.Cos(rad(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EARTH_KM * math.Asin(math.Sqrt(h))
+}
+
+/*
+Return the location of the home arena, given by name or as LAT,LON.
+*/
+func homeLocation(arenas map[string]arena_t, home string) (arena_t, error) {
+	if lat, lon, ok := strings.Cut(home, ","); ok {
+		la, errLat := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+		lo, errLon := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+		if errLat == nil && errLon == nil {
+			return arena_t{Lat: la, Lon: lo}, nil
+		}
+	}
+	if arena, ok := arenas[strings.ToLower(strings.TrimSpace(home))]; ok {
+		return arena, nil
+	}
+	return arena_t{}, fmt.Errorf("no location for home arena %s, add it to %s or give it as LAT,LON",
+		home, ARENAS_FILE)
+}
+
+/*
+Return the distance from the home arena to each arena in the games, by the
+arena name in the schedule, and the names of the arenas with no location.
+*/
+func venueDistances(arenas map[string]arena_t, home arena_t, games [][]string) (map[string]float64, []string) {
+	distances := make(map[string]float64)
+	var unknown []string
+	for _, game := range games {
+		venue := game[schedule.VENUE]
+		if _, done := distances[venue]; done || slices.Contains(unknown, venue) {
+			continue
+		}
+		if arena, ok := arenas[strings.ToLower(strings.TrimSpace(venue))]; ok {
+			distances[venue] = distanceKm(home, arena)
+		} else {
+			unknown = append(unknown, venue)
+		}
+	}
+	slices.Sort(unknown)
+	return distances, unknown
+}
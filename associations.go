@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Cross-association search
+
+ Neighbouring associations hosted on TTM can be searched for exhibition-style
+ swaps by loading their schedules with -orgs NAME=ORGID,... Their divisions
+ are prefixed with the association name (i.e. "OMHA: U13 B") so their games
+ and teams can be told apart in the report. Unless other rules are given,
+ their divisions are matched with the same swap rules as ours.
+
+ Rules for which of their divisions are compatible with ours are given in a
+ JSON file with -association-rules, by association then by our division, i.e.
+
+   {"OMHA": {"U13 B": "U13.*[B-C]", "U13 C": "U13.*[B-C]"}}
+
+ When rules are given, only the associations and divisions in them are
+ searched.
+*/
+
+// Patterns of the compatible divisions by association, then by our division
+type associationRules map[string]map[string]string
+
+// Structure to hold another association to search
+type association_t struct {
+	name  string // short name used to prefix the divisions
+	orgID string // TTM organization id of the schedule
+}
+
+/*
+Parse a comma separated list of NAME=ORGID associations.
+*/
+func parseAssociations(list string) ([]association_t, error) {
+	var associations []association_t
+	for _, entry := range strings.Split(list, ",") {
+		name, orgID, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		name, orgID = strings.TrimSpace(name), strings.TrimSpace(orgID)
+		if !ok || name == "" || orgID == "" {
+			return nil, fmt.Errorf("invalid association %q, expected NAME=ORGID", entry)
+		}
+		associations = append(associations, association_t{name: name, orgID: orgID})
+	}
+	return associations, nil
+}
+
+/*
+Download the schedules of the other associations, with the divisions
+prefixed by the association name.
+*/
+func associationSchedules(associations []association_t) ([]schedule.Record, error) {
+	var records []schedule.Record
+	for _, association := range associations {
+		schedule, err := downloadSchedule(association.orgID)
+		if err != nil {
+			return nil, fmt.Errorf("%s schedule: %w", association.name, err)
+		}
+		for _, record := range schedule {
+			record.Division = association.name + ": " + record.Division
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+/*
+Read the association rules file and check the patterns compile.
+*/
+func readAssociationRules(path string) (associationRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules associationRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for name, divisions := range rules {
+		for division, expr := range divisions {
+			if _, err := regexp.Compile(expr); err != nil {
+				return nil, fmt.Errorf("%s rule for %s: %w", name, division, err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+/*
+Return the pattern of the divisions that can swap with a division. Our
+divisions have no association prefix, so our swap rules can't match past
+the colon into another association's division. Without rules, all
+associations use our swap rules.
+*/
+func swappableExpr(division swap.Division, rules associationRules) string {
+	if rules == nil {
+		return division.SwapsRegex
+	}
+	exprs := []string{"^[^:]*(?:" + division.SwapsRegex + ")"}
+	for name, divisions := range rules {
+		if expr, ok := divisions[division.Name]; ok {
+			exprs = append(exprs, "^"+regexp.QuoteMeta(name)+": (?:"+expr+")")
+		}
+	}
+	return strings.Join(exprs, "|")
+}
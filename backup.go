@@ -0,0 +1,244 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Backup and restore
+
+ The setup kept between runs (the swap tracker, division rules, corrections,
+ contact overrides, notifiers and plugins) can be saved to one zip archive
+ and restored from it, i.e. to move to a new laptop part way through the
+ season:
+
+   go-scheduler backup
+   go-scheduler restore go-scheduler-backup-2025-01-12.zip
+
+ Downloaded files (the schedule, contacts and cache) aren't included as they
+ are fetched again. Credentials are only included when asked for, the
+ archive isn't encrypted and the credentials file holds passwords.
+ Restoring doesn't replace existing files unless forced.
+*/
+
+// Files of the setup, the ones that don't exist are skipped
+var backupFiles = []string{"tracker.json", DIVISIONS_FILE, "corrections.json",
+	"contact-overrides.json", "notifiers.json"}
+
+// Directories of the setup, backed up with everything in them
+var backupDirs = []string{"plugins"}
+
+/*
+Add a file to the archive under its slash separated path, keeping its mode.
+*/
+func addToArchive(archive *zip.Writer, name string, fi fs.FileInfo) error {
+	header, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(name)
+	header.Method = zip.Deflate
+	w, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+/*
+Write the setup files to a zip archive. Returns the names of the files
+archived.
+*/
+func writeBackup(fileName string, files, dirs []string) ([]string, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	var archived []string
+	add := func(name string, fi fs.FileInfo) error {
+		if err := addToArchive(archive, name, fi); err != nil {
+			return err
+		}
+		archived = append(archived, name)
+		return nil
+	}
+
+	for _, name := range files {
+		fi, err := os.Stat(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := add(name, fi); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			fi, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			return add(name, fi)
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	archive.SetComment("go-scheduler backup " + time.Now().Format(time.RFC3339))
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return archived, writeFileAtomic(fileName, buf.Bytes(), 0600)
+}
+
+/*
+Return the local path for a name in the archive, or an error if it would be
+outside the current directory.
+*/
+func restorePath(name string) (string, error) {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(clean, ":") {
+		return "", fmt.Errorf("unsafe path %s in backup", name)
+	}
+	return filepath.FromSlash(clean), nil
+}
+
+/*
+Restore the files in a backup archive to the current directory. Existing
+files are skipped unless force is set. Returns the names of the files
+restored and skipped.
+*/
+func restoreBackup(fileName string, force bool) (restored, skipped []string, err error) {
+	archive, err := zip.OpenReader(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name, err := restorePath(f.Name)
+		if err != nil {
+			return restored, skipped, err
+		}
+		if _, err := os.Stat(name); err == nil && !force {
+			skipped = append(skipped, name)
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return restored, skipped, err
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return restored, skipped, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+			return restored, skipped, err
+		}
+		if err := writeFileAtomic(name, data, f.Mode().Perm()); err != nil {
+			return restored, skipped, err
+		}
+		restored = append(restored, name)
+	}
+	return restored, skipped, nil
+}
+
+/*
+Save the setup to a zip archive.
+
+	backup [-o file] [-tracker file] [-credentials] [FILE...]
+
+Other files to include, i.e. an email template, can be listed after the
+options.
+*/
+func backupCommand(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := flags.String("o", "go-scheduler-backup-"+time.Now().Format(schedule.DATE_FORMAT)+".zip",
+		"Archive to write")
+	trackerFile := flags.String("tracker", "tracker.json", "File the swap tracker is stored in")
+	withCredentials := flags.Bool("credentials", false,
+		"Include the credentials file, the archive isn't encrypted")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler backup [options] [FILE...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	files := append([]string{*trackerFile}, backupFiles[1:]...)
+	if *withCredentials {
+		files = append(files, credentialsFile)
+	}
+	files = append(files, flags.Args()...)
+
+	archived, err := writeBackup(*output, files, backupDirs)
+	if err != nil {
+		return err
+	}
+	for _, name := range archived {
+		fmt.Println("  " + name)
+	}
+	fmt.Printf("Backed up %d files to %s\n", len(archived), *output)
+	return nil
+}
+
+/*
+Restore the setup from a zip archive written by backup.
+
+	restore [-force] ARCHIVE
+*/
+func restoreCommand(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	force := flags.Bool("force", false, "Replace files that already exist")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler restore [options] ARCHIVE")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("restore needs the backup archive")
+	}
+	restored, skipped, err := restoreBackup(flags.Arg(0), *force)
+	for _, name := range restored {
+		fmt.Println("  " + name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped %d files that already exist (use -force to replace): %s\n",
+			len(skipped), strings.Join(skipped, ", "))
+	}
+	fmt.Printf("Restored %d files from %s\n", len(restored), flags.Arg(0))
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Rink-board output
+
+ A one page summary of the potential matches to print and pin to the
+ dressing room board, so parents can mark which dates they can make before a
+ swap is agreed. Only the date, time, teams and a contact name are shown, in
+ a large font, with a box to tick for each match. The feed has no phone
+ numbers so the contact is the manager's name, or the coach's.
+*/
+
+// Most matches that fit on the printed page
+const BOARD_ROWS = 12
+
+// Board output
+type boardOutput struct{}
+
+func (boardOutput) Ext() string { return ".board.html" }
+
+// Structure to hold a match on the board
+type boardRow_t struct {
+	Date     string // i.e. Sat Jan 12
+	Time     string
+	Teams    string // i.e. X vs Y
+	Arena    string
+	Contact  string
+	Warnings bool // has a warning, i.e. short-staffed
+}
+
+var boardTemplate = template.Must(template.New("board").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Swap {{.Game}}</title>
+<style>
+@page { size: letter; margin: 1.5cm; }
+body { font-family: sans-serif; font-size: 20pt; }
+h1 { font-size: 30pt; margin: 0 0 0.2em; }
+p { margin: 0 0 0.8em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 2px solid black; padding: 0.25em 0.4em; text-align: left; }
+td.box { width: 1.5em; }
+.warn { font-style: italic; }
+</style>
+</head>
+<body>
+<h1>Can we move {{.Game}}?</h1>
+<p>{{.Date}}: {{.Home}} vs {{.Away}}. Tick the dates you can make.</p>
+<table>
+<tr><th></th><th>Date</th><th>Time</th><th>Game</th><th>Arena</th><th>Contact</th></tr>
+{{range .Rows}}<tr{{if .Warnings}} class="warn"{{end}}><td class="box"></td><td>{{.Date}}</td><td>{{.Time}}</td><td>{{.Teams}}</td><td>{{.Arena}}</td><td>{{.Contact}}</td></tr>
+{{else}}<tr><td class="box"></td><td colspan="5">No potential matches found</td></tr>
+{{end}}</table>
+{{if .More}}<p>and {{.More}} more</p>{{end}}
+</body>
+</html>
+`))
+
+/*
+Return a date in the short form used on the board, i.e. Sat Jan 12. The date
+is returned as is if it can't be parsed.
+*/
+func boardDate(date string) string {
+	d, err := time.Parse(schedule.DATE_FORMAT, date)
+	if err != nil {
+		return date
+	}
+	return d.Format("Mon Jan 2")
+}
+
+func (boardOutput) Write(w io.Writer, report report_t) error {
+	s := report.swap
+	var rows []boardRow_t
+	for _, g := range s.Games[:min(len(s.Games), BOARD_ROWS)] {
+		contact := report.contacts[g[schedule.HOMETEAM]]
+		name := contact.Manager
+		if name == "" {
+			name = contact.Coach
+		}
+		rows = append(rows, boardRow_t{
+			Date:     boardDate(g[schedule.DATE]),
+			Time:     g[schedule.TIME],
+			Teams:    g[schedule.HOMETEAM] + " vs " + g[schedule.AWAYTEAM],
+			Arena:    g[schedule.VENUE],
+			Contact:  name,
+			Warnings: len(report.warnings[g[schedule.GAMEID]]) > 0,
+		})
+	}
+	return boardTemplate.Execute(w, map[string]any{
+		"Game": s.GameID,
+		"Date": boardDate(s.Date),
+		"Home": s.Home,
+		"Away": s.Away,
+		"Rows": rows,
+		"More": len(s.Games) - len(rows),
+	})
+}
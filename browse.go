@@ -0,0 +1,330 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Browsing the potential matches
+
+ With -browse the search doesn't end at "press enter to continue", the
+ potential matches are listed a page at a time and can be worked through
+ from the terminal:
+
+   n, p, l         next or previous page, or list this page again
+   g GAMEID        switch to the matches of another game searched
+   v N             view match N with its contacts, notes and warnings
+   c N             mark match N as contacted
+   d N [REASON]    mark match N as declined, for the reason given
+   s N             add match N to the shortlist, or take it off
+   cmp N M [O]     compare 2 or 3 matches side by side
+   x               export the shortlist to <gameId>-shortlist
+   q or enter      quit
+
+ Responses are saved to the tracker as they are marked, and the reports are
+ written again on the way out so they show them too.
+*/
+
+// Number of potential matches listed at a time
+const BROWSE_PAGE = 10
+
+// Structure to hold the state of browsing the reports
+type browser_t struct {
+	reports     []report_t
+	current     int                 // report being browsed
+	page        int                 // page of the report's matches
+	shortlist   map[string][]string // shortlisted match ids by swap game id
+	changed     map[string]bool     // swap game ids with new responses
+	trackerFile string
+	output      OutputWriter
+}
+
+/*
+Browse the potential matches of the reports until the user quits.
+*/
+func browse(reports []report_t, trackerFile string, output OutputWriter) error {
+	b := &browser_t{
+		reports:     reports,
+		shortlist:   make(map[string][]string),
+		changed:     make(map[string]bool),
+		trackerFile: trackerFile,
+		output:      output,
+	}
+	b.list()
+	for {
+		fmt.Print("browse (? for help)> ")
+		fields := strings.Fields(readLine())
+		if len(fields) == 0 || fields[0] == "q" {
+			return b.rewrite()
+		}
+		if err := b.command(fields[0], fields[1:]); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+/*
+Run a browsing command.
+*/
+func (b *browser_t) command(name string, args []string) error {
+	report := &b.reports[b.current]
+	switch name {
+	case "?", "h", "help":
+		fmt.Println("n/p next/previous page, l list, g GAMEID switch game, v N view, c N contacted,")
+		fmt.Println("d N [REASON] declined (" + strings.Join(declineReasons, ", ") + "),")
+		fmt.Println("s N shortlist, cmp N M [O] compare, x export shortlist, q or enter quit")
+	case "n":
+		if (b.page+1)*BROWSE_PAGE >= len(report.swap.Games) {
+			return fmt.Errorf("already on the last page")
+		}
+		b.page++
+		b.list()
+	case "p":
+		if b.page == 0 {
+			return fmt.Errorf("already on the first page")
+		}
+		b.page--
+		b.list()
+	case "l":
+		b.list()
+	case "g":
+		if len(args) != 1 {
+			return fmt.Errorf("g needs the id of a game searched")
+		}
+		idx := slices.IndexFunc(b.reports, func(r report_t) bool { return strings.EqualFold(r.swap.GameID, args[0]) })
+		if idx < 0 {
+			return fmt.Errorf("game %s wasn't searched", args[0])
+		}
+		b.current, b.page = idx, 0
+		b.list()
+	case "v":
+		game, err := b.match(args)
+		if err != nil {
+			return err
+		}
+		b.view(game)
+	case "c":
+		game, err := b.match(args)
+		if err != nil {
+			return err
+		}
+		return b.respond(game[schedule.GAMEID], func(tracker *tracker_t) {
+			tracker.setResponses(report.swap.GameID, map[string]string{game[schedule.GAMEID]: "Contacted"})
+		}, "Contacted")
+	case "d":
+		game, err := b.match(args)
+		if err != nil {
+			return err
+		}
+		reason := "other"
+		if len(args) > 1 {
+			reason = strings.ToLower(args[1])
+		}
+		if !slices.Contains(declineReasons, reason) {
+			return fmt.Errorf("unknown reason %s (available: %s)", reason, strings.Join(declineReasons, ", "))
+		}
+		return b.respond(game[schedule.GAMEID], func(tracker *tracker_t) {
+			tracker.addDecline(report.swap.GameID, game[schedule.GAMEID], reason)
+		}, "Declined")
+	case "s":
+		game, err := b.match(args)
+		if err != nil {
+			return err
+		}
+		gameId, id := report.swap.GameID, game[schedule.GAMEID]
+		if i := slices.Index(b.shortlist[gameId], id); i >= 0 {
+			b.shortlist[gameId] = slices.Delete(b.shortlist[gameId], i, i+1)
+			fmt.Printf("Took %s off the shortlist\n", id)
+		} else {
+			b.shortlist[gameId] = append(b.shortlist[gameId], id)
+			fmt.Printf("Shortlisted %s (%d)\n", id, len(b.shortlist[gameId]))
+		}
+	case "cmp":
+		var ids []string
+		for _, arg := range args {
+			game, err := b.match([]string{arg})
+			if err != nil {
+				return err
+			}
+			ids = append(ids, game[schedule.GAMEID])
+		}
+		fmt.Println()
+		return report.compare(os.Stdout, ids)
+	case "x":
+		return b.export()
+	default:
+		return fmt.Errorf("unknown command %s, ? for help", name)
+	}
+	return nil
+}
+
+/*
+Return the potential match numbered by the first argument.
+*/
+func (b *browser_t) match(args []string) ([]string, error) {
+	games := b.reports[b.current].swap.Games
+	if len(args) == 0 {
+		return nil, fmt.Errorf("which match? give its number")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(games) {
+		return nil, fmt.Errorf("no match number %s, there are %d", args[0], len(games))
+	}
+	return games[n-1], nil
+}
+
+/*
+List the current page of potential matches.
+*/
+func (b *browser_t) list() {
+	report := b.reports[b.current]
+	s := report.swap
+	games := s.Games
+	fmt.Printf("\n%s on %s, %s vs %s: %d potential matches\n", s.GameID, s.Date, s.Home, s.Away,
+		len(games))
+	if len(games) == 0 {
+		return
+	}
+	start := b.page * BROWSE_PAGE
+	end := min(start+BROWSE_PAGE, len(games))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i := start; i < end; i++ {
+		g := games[i]
+		mark := " "
+		if slices.Contains(b.shortlist[s.GameID], g[schedule.GAMEID]) {
+			mark = "*"
+		}
+		flag := ""
+		if len(report.warnings[g[schedule.GAMEID]]) > 0 {
+			flag = "!"
+		}
+		fmt.Fprintf(w, "%s%3d\t%s\t%s\t%s\t%s\t%s\t%s vs %s\t%s\t%s\n", mark, i+1, report.tiers[g[schedule.GAMEID]],
+			g[schedule.GAMEID], g[schedule.DATE], g[schedule.TIME], g[schedule.VENUE], g[schedule.HOMETEAM],
+			g[schedule.AWAYTEAM], report.responses[g[schedule.GAMEID]], flag)
+	}
+	w.Flush()
+	fmt.Printf("page %d of %d, * shortlisted, ! has warnings\n", b.page+1, (len(games)+BROWSE_PAGE-1)/BROWSE_PAGE)
+}
+
+/*
+Show a potential match with the contacts of its teams, its notes and
+warnings.
+*/
+func (b *browser_t) view(game []string) {
+	report := b.reports[b.current]
+	id := game[schedule.GAMEID]
+	fmt.Printf("\n%s  %s  %s %s at %s\n", id, game[schedule.DIVISION], game[schedule.DATE], game[schedule.TIME],
+		game[schedule.VENUE])
+	fmt.Printf("%s vs %s\n", game[schedule.HOMETEAM], game[schedule.AWAYTEAM])
+	if score, ok := report.scores[id]; ok {
+		fmt.Printf("Score: %.1f %s\n", score, report.tiers[id])
+	}
+	for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+		if report.redact {
+			fmt.Printf("  %s: %s\n", team, contacts.Labels(report.contacts, team))
+			continue
+		}
+		contact, ok := report.contacts[team]
+		if !ok {
+			fmt.Printf("  %s: no contacts\n", team)
+			continue
+		}
+		fmt.Printf("  %s\n", team)
+		if contact.CoachEmail != "" {
+			fmt.Printf("    coach    %s <%s>\n", contact.Coach, contact.CoachEmail)
+		}
+		if contact.ManagerEmail != "" {
+			fmt.Printf("    manager  %s <%s>\n", contact.Manager, contact.ManagerEmail)
+		}
+		for _, other := range contact.Others {
+			fmt.Printf("    other    %s\n", other)
+		}
+	}
+	if response := report.responses[id]; response != "" {
+		fmt.Println("Response: ", response)
+	}
+	if notes := report.notes[id]; len(notes) > 0 {
+		fmt.Println("Notes: ", formatNotes(notes))
+	}
+	for _, warning := range report.warnings[id] {
+		fmt.Println("Warning: ", warning)
+	}
+}
+
+/*
+Record a response in the tracker and in the report being browsed.
+*/
+func (b *browser_t) respond(matchId string, change func(*tracker_t), response string) error {
+	report := &b.reports[b.current]
+	if _, err := updateTracker(b.trackerFile, change); err != nil {
+		return err
+	}
+	responses := maps.Clone(report.responses)
+	if responses == nil {
+		responses = make(map[string]string)
+	}
+	responses[matchId] = response
+	report.responses = responses
+	b.changed[report.swap.GameID] = true
+	fmt.Printf("Marked %s %s\n", matchId, response)
+	return nil
+}
+
+/*
+Write the shortlisted matches of the current game in the report's format.
+*/
+func (b *browser_t) export() error {
+	report := b.reports[b.current]
+	ids := b.shortlist[report.swap.GameID]
+	if len(ids) == 0 {
+		return fmt.Errorf("nothing shortlisted for %s, s N adds a match", report.swap.GameID)
+	}
+	// Keep the order of the report, not the order they were picked in
+	report.swap.Games = slices.DeleteFunc(slices.Clone(report.swap.Games), func(game []string) bool {
+		return !slices.Contains(ids, game[schedule.GAMEID])
+	})
+	report.summary = false
+	fileName := report.swap.GameID + "-shortlist" + b.output.Ext()
+	if err := b.write(fileName, report); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d matches to %s\n", len(report.swap.Games), fileName)
+	return nil
+}
+
+/*
+Write the reports with new responses again, so the responses are in them.
+*/
+func (b *browser_t) rewrite() error {
+	for _, report := range b.reports {
+		if b.changed[report.swap.GameID] {
+			if err := b.write(report.swap.GameID+b.output.Ext(), report); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Write a report to a file.
+*/
+func (b *browser_t) write(fileName string, report report_t) error {
+	var buf bytes.Buffer
+	if err := b.output.Write(&buf, report); err != nil {
+		return err
+	}
+	return writeFileAtomic(fileName, buf.Bytes(), 0644)
+}
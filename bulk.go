@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ District-wide bulk rescheduling
+
+ Used by association schedulers when a number of games are displaced at once
+ (i.e. an arena closure). A swap would hand the displaced game's slot to the
+ other game, putting it in the closed arena, so displaced games are only
+ moved into open ice: permitted slots from the city's export (-ice-permits)
+ that no game is using. Slots at the arenas of the displaced games are never
+ used, those arenas are taken to be closed.
+
+ Each open slot is used once and, after all of the moves, no team plays twice
+ on the same day. Games with the fewest open slots are placed first so that
+ the options for the hard to place games aren't used up by the easy ones.
+*/
+
+// Structure to hold a displaced game and the slot it is moved to
+type move_t struct {
+	game    []string // displaced game
+	options []slot_t // open slots the game could move to
+	slot    *slot_t  // slot assigned, nil if none found
+}
+
+/*
+Read the game ids from a file. Blank lines and lines starting with # are
+ignored.
+*/
+func readGameIds(fileName string) ([]string, error) {
+	fi, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer fi.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(fi)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	return ids, scanner.Err()
+}
+
+// Count of the games each team plays on each date
+type busy_t map[string]map[string]int
+
+/*
+Count the games each team plays on each date of the schedule.
+*/
+func newBusy(games [][]string) busy_t {
+	busy := make(busy_t)
+	for _, game := range games {
+		busy.play(game[schedule.DATE], 1, game[schedule.HOMETEAM], game[schedule.AWAYTEAM])
+	}
+	return busy
+}
+
+/*
+Add (or remove with a negative delta) a game for the teams on the date.
+*/
+func (busy busy_t) play(date string, delta int, teams ...string) {
+	if busy[date] == nil {
+		busy[date] = make(map[string]int)
+	}
+	for _, team := range teams {
+		busy[date][swap.NormalizeTeam(team)] += delta
+	}
+}
+
+/*
+Check that none of the teams are playing on the date.
+*/
+func (busy busy_t) free(date string, teams ...string) bool {
+	for _, team := range teams {
+		if busy[date][swap.NormalizeTeam(team)] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Return the arenas of the displaced games, in upper case, which are closed.
+*/
+func closedArenas(moves []move_t) map[string]bool {
+	closed := make(map[string]bool)
+	for _, move := range moves {
+		closed[strings.ToUpper(strings.TrimSpace(move.game[schedule.VENUE]))] = true
+	}
+	return closed
+}
+
+/*
+Assign an open slot to each displaced game. The schedule must contain every
+game so that conflicts in other divisions are detected. Slots at the closed
+arenas are never assigned.
+*/
+func assignMoves(games [][]string, moves []move_t) {
+	// create a debugger object
+	var debug = debuggo.Debug("assignMoves")
+
+	// Track the games each team plays on each date. This is updated as
+	// games are moved so later games are checked against the new schedule.
+	// The displaced games no longer take place on their dates.
+	busy := newBusy(games)
+	for _, move := range moves {
+		busy.play(move.game[schedule.DATE], -1, move.game[schedule.HOMETEAM], move.game[schedule.AWAYTEAM])
+	}
+
+	closed := closedArenas(moves)
+	taken := make(map[slot_t]bool)
+
+	slices.SortStableFunc(moves, func(a, b move_t) int {
+		return len(a.options) - len(b.options)
+	})
+
+	for i := range moves {
+		g := moves[i].game
+		for _, slot := range moves[i].options {
+			if closed[strings.ToUpper(slot.venue)] {
+				debug("%s -> %v << closed arena", g[schedule.GAMEID], slot)
+				continue
+			}
+			if taken[slot] {
+				continue
+			}
+			if !busy.free(slot.date, g[schedule.HOMETEAM], g[schedule.AWAYTEAM]) {
+				debug("%s -> %v << team already playing", g[schedule.GAMEID], slot)
+				continue
+			}
+			busy.play(slot.date, 1, g[schedule.HOMETEAM], g[schedule.AWAYTEAM])
+			taken[slot] = true
+			moves[i].slot = &slot
+			break
+		}
+	}
+}
+
+/*
+Build the moves for the games with the open slots each one could move to.
+Games that are missing from the schedule or before the cut off date are
+reported and skipped.
+*/
+func buildMoves(games [][]string, ids []string, open []slot_t, cutOffDate time.Time) ([]move_t, error) {
+	// create a debugger object
+	var debug = debuggo.Debug("buildMoves")
+
+	var moves []move_t
+	for _, id := range ids {
+		idx := slices.IndexFunc(games, func(game []string) bool { return game[schedule.GAMEID] == id })
+		if idx < 0 {
+			fmt.Println("Skipping game not in the schedule: ", id)
+			continue
+		}
+		game := games[idx]
+
+		gameDate, err := time.Parse(schedule.DATE_FORMAT, game[schedule.DATE])
+		if err != nil {
+			return nil, err
+		}
+		if gameDate.Before(cutOffDate) {
+			fmt.Println("Game is before the cut off date: ", id)
+			continue
+		}
+		moves = append(moves, move_t{game: game})
+	}
+
+	// Only the ice away from the closed arenas is worth counting
+	closed := closedArenas(moves)
+	for i := range moves {
+		for _, slot := range open {
+			if !closed[strings.ToUpper(slot.venue)] {
+				moves[i].options = append(moves[i].options, slot)
+			}
+		}
+		debug("%s has %d open slots", moves[i].game[schedule.GAMEID], len(moves[i].options))
+	}
+	return moves, nil
+}
+
+/*
+Find a consistent set of moves into open ice for all of the games listed in
+the file and write them to <file>-moves.csv, with the contacts labelled when
+they are redacted.
+*/
+func bulkReschedule(games [][]string, fileName, permitFile string, cutOffDate time.Time,
+	contactList map[string]contacts.Contact, redact bool) error {
+	// create a debugger object
+	var debug = debuggo.Debug("bulkReschedule")
+
+	if permitFile == "" {
+		return errors.New("-bulk needs -ice-permits for the open ice to move the displaced games to")
+	}
+	ids, err := readGameIds(fileName)
+	if err != nil {
+		return err
+	}
+	slots, err := readIcePermits(permitFile)
+	if err != nil {
+		return err
+	}
+
+	moves, err := buildMoves(games, ids, emptySlots(games, slots, cutOffDate), cutOffDate)
+	if err != nil {
+		return err
+	}
+	assignMoves(games, moves)
+
+	outName := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "-moves.csv"
+	debug("Creating output file: %s", outName)
+	csvFile, err := os.Create(outName)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	writer.Write([]string{"Division", "Game ID", "Date", "Time", "Arena", "Home Team", "Away Team",
+		"New Date", "New Time", "New Arena", "Contacts"})
+
+	resolved := 0
+	for _, move := range moves {
+		g := move.game
+		row := slices.Clone(g[:schedule.AWAYTEAM+1])
+		emails := contactsFor(redact)(contactList, g[schedule.HOMETEAM], g[schedule.AWAYTEAM])
+		if move.slot == nil {
+			fmt.Printf("%s: no open slot found\n", g[schedule.GAMEID])
+			writer.Write(append(row, "", "", "", emails))
+			continue
+		}
+		slot := move.slot
+		resolved++
+		fmt.Printf("%s -> %s %s at %s\n", g[schedule.GAMEID], slot.date, slot.time, slot.venue)
+		writer.Write(append(row, slot.date, slot.time, slot.venue, emails))
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Found open slots for %d of %d games, recorded to %s\n", resolved, len(moves), outName)
+	return nil
+}
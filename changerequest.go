@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Game change requests
+
+ An accepted swap is written as a game change request, ready to copy into
+ the association's change request form. The format is this tool's own, it
+ isn't the league's template or the TTM change request fields, neither of
+ which is published. Both games in the swap are listed, each moving into the
+ other's time slot.
+*/
+
+// Header of the change request, the current and requested slot of each game
+var changeRequestHeader = []string{
+	"Game ID", "Division", "Home Team", "Away Team",
+	"Current Date", "Current Time", "Current Arena",
+	"Requested Date", "Requested Time", "Requested Arena",
+	"Reason",
+}
+
+/*
+Return the change request row for moving a game into the time slot of
+another game.
+*/
+func changeRequestRow(game, slot []string, reason string) []string {
+	return []string{
+		game[schedule.GAMEID], game[schedule.DIVISION], game[schedule.HOMETEAM], game[schedule.AWAYTEAM],
+		game[schedule.DATE], game[schedule.TIME], game[schedule.VENUE],
+		slot[schedule.DATE], slot[schedule.TIME], slot[schedule.VENUE],
+		reason,
+	}
+}
+
+/*
+Write the change request for an accepted swap to <gameId>-change.csv.
+The accepted game must be one of the potential matches for the swap.
+*/
+func writeChangeRequest(s swap.Swap, acceptId string) error {
+	// create a debugger object
+	var debug = debuggo.Debug("writeChangeRequest")
+
+	idx := slices.IndexFunc(s.Games, func(game []string) bool {
+		return game[schedule.GAMEID] == acceptId
+	})
+	if idx < 0 {
+		return fmt.Errorf("game %s is not a potential match for %s", acceptId, s.GameID)
+	}
+	accepted := s.Games[idx]
+
+	fileName := s.GameID + "-change.csv"
+	debug("Creating change request file: %s", fileName)
+	csvFile, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	writer.Write(changeRequestHeader)
+	writer.Write(changeRequestRow(s.Game, accepted, "Swap with game "+acceptId))
+	writer.Write(changeRequestRow(accepted, s.Game, "Swap with game "+s.GameID))
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recorded change request for %s <-> %s to %s\n", s.GameID, acceptId, fileName)
+	return nil
+}
@@ -0,0 +1,809 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+func main() {
+	handleSignals(os.Interrupt, syscall.SIGTERM)
+	if err := run(); err != nil {
+		eventLog.Print("error: ", err)
+		log.Fatal(err)
+	}
+}
+
+/*
+Run the sub-command, or search for swaps for the games entered.
+*/
+func run() error {
+	// create a debugger object
+	var debug = debuggo.Debug("main")
+
+	// Structure to hold swap information
+	var s swap.Swap
+
+	// Associations can have their own division rules
+	if err := loadDivisions(DIVISIONS_FILE); err != nil {
+		return err
+	}
+
+	// Sub-commands have their own options
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			return command(os.Args[2:])
+		}
+	}
+
+	// Command line options
+	packageId := flag.String("package", "",
+		"Id of the return game between the same two teams for a two-game package swap")
+	bulkFile := flag.String("bulk", "",
+		"File listing the ids of displaced games (one per line) to move into the open -ice-permits slots together")
+	source := flag.String("source", "",
+		"Name of the plugin to get the schedule and contacts from instead of TTM")
+	pluginDir := flag.String("plugins", "plugins", "Directory containing data source plugins")
+	orgs := flag.String("orgs", "",
+		"Comma separated NAME=ORGID of other associations on TTM to search for swaps")
+	divisionsFile := flag.String("divisions", DIVISIONS_FILE,
+		"JSON file of division swap rules to use instead of the built in ones, can't be used with -preset")
+	rulesFile := flag.String("association-rules", "",
+		"JSON file of the other associations' divisions compatible with ours")
+	matchFiles := flag.String("match", "",
+		"Comma separated files of game ids that teams need to move, to be paired with each other")
+	permitFile := flag.String("ice-permits", "",
+		"City ice permit CSV export used to find open slots to reschedule into")
+	acceptId := flag.String("accept", "",
+		"Id of the accepted swap game, writes a game change request (<gameId>-change.csv) instead of the matches")
+	correctionsFile := flag.String("corrections", "corrections.json",
+		"JSON file of corrected dates, times and arenas by game id, applied over the schedule")
+	ttl := flag.Duration("schedule-ttl", scheduleTTL,
+		"How long a downloaded schedule is used before downloading it again")
+	refresh := flag.Bool("refresh", false, "Download the schedule even if the last download is recent")
+	retries := flag.Int("fetch-retries", fetchRetries,
+		"Number of times a failed schedule or contacts download is retried")
+	timeout := flag.Duration("fetch-timeout", fetchClient.Timeout,
+		"Time allowed for each schedule or contacts download")
+	saveSchedule := flag.Bool("save-schedule", false, "Save the downloaded schedule to schedule.csv")
+	saveContacts := flag.Bool("save-contacts", false, "Save the downloaded team contacts to contacts.json")
+	trackerFile := flag.String("tracker", "tracker.json", "File the swap tracker is stored in")
+	extraColumnsFlag := flag.Bool("extra-columns", false,
+		"Include extra schedule columns (i.e. status, score) in the output")
+	columns := flag.String("columns", "",
+		"Comma separated columns to output (i.e. date,time,venue,away,contacts)")
+	summary := flag.Bool("summary", true, "Add a summary of the potential matches to the output (<gameId>-summary.csv for CSV)")
+	freeMatrixFlag := flag.Bool("free-matrix", false,
+		"Write a matrix of the weekends each candidate team is free to <gameId>-free.csv")
+	includeDeclined := flag.Bool("include-declined", false,
+		"Include teams and games that have declined before")
+	format := flag.String("format", "csv", "Output format for the potential matches")
+	scoringFile := flag.String("scoring", "scoring.json",
+		"JSON file of the weights for scoring the potential matches (sameDay, timeSlot, sameVenue, closeDate, shortStaffed)")
+	tiersFlag := flag.String("tiers", swap.DefaultTiers.String(),
+		"Percent of the best score a potential match needs to be strong and to be possible, the rest are long shots")
+	rank := flag.String("rank", swap.DEFAULT_RANKING,
+		"Comma separated order of the potential matches, ties broken by the next (chronological, score, distance, responsive)")
+	rankResponsive := flag.Bool("rank-responsive", false,
+		"List the potential matches with teams that reply to contacts most often and fastest first, same as -rank responsive,...")
+	contactsFile := flag.String("contacts-file", "",
+		"CSV file of supplemental contacts (Team, Role, Name, Email) to merge with the feed")
+	contactPrecedence := flag.String("contacts-precedence", "feed",
+		"Source used when the feed and the contacts file both have a coach or manager (feed or file)")
+	overridesFile := flag.String("contact-overrides", "contact-overrides.json",
+		"JSON file of corrected contacts by team name, applied over all other contacts")
+	redactContacts := flag.Bool("redact-contacts", false,
+		"Replace contact emails with team and role labels so the report can be shared")
+	maxPerDivision := flag.Int("max-per-division", 0,
+		"Most potential matches to list from each division (0 for no limit)")
+	maxPerTeam := flag.Int("max-per-team", 0, "Most potential matches to list with each team (0 for no limit)")
+	repeatDays := flag.Int("repeat-days", 0,
+		"Warn about swaps that have the same two teams meet again within this many days")
+	arenasFile := flag.String("arenas", ARENAS_FILE, "JSON file of arena locations by arena name")
+	homeArena := flag.String("home-arena", "",
+		"Home arena name, or LAT,LON, to measure the distance to the potential matches' arenas from")
+	maxKm := flag.Float64("max-km", 0, "Leave out potential matches at arenas farther than this many km (0 for no limit)")
+	rankDistance := flag.Bool("rank-distance", false,
+		"List the potential matches at the nearest arenas first, same as -rank distance,...")
+	days := flag.String("days", "", "Comma separated days of the week the team can play (i.e. sat,sun)")
+	after := flag.String("after", "", "Earliest start time the team can play, HH:MM")
+	before := flag.String("before", "", "Latest start time the team can play, HH:MM")
+	household := flag.String("household", "",
+		"Comma separated names of other teams in the family, their game dates are left out")
+	team := flag.String("team", "",
+		"Search for swaps for all of this team's games instead of entering game ids")
+	dates := flag.String("dates", "",
+		"Date range of the team's games to swap, i.e. 2030-01-01..2030-01-31 (all after the cut off if empty)")
+	excludeTeams := flag.String("exclude-teams", "",
+		"Comma separated teams to leave out (i.e. away at a tournament), prompted for along with the game ids if not given")
+	emailDrafts := flag.Bool("email-drafts", false,
+		"Write a draft swap request email for each potential match to <gameId>-emails")
+	emailTemplate := flag.String("email-template", "",
+		"Template file for the swap request emails, the first line is the subject if it starts with Subject:")
+	emailFrom := flag.String("email-from", "", "From address for the swap request emails")
+	sendEmails := flag.Bool("send-emails", false,
+		"Send the swap request emails through the SMTP server, after confirming")
+	dryRun := flag.Bool("dry-run", false, "List the swap request emails -send-emails would send without sending")
+	logFile := flag.String("log", "",
+		"File to also log the searches, notifications and errors to, rotated as it grows (i.e. "+LOG_FILE+")")
+	curfew := flag.String("curfew", "", "Latest time the team's games can end, HH:MM, shown when comparing matches")
+	compareIds := flag.String("compare", "",
+		"Comma separated ids of 2 or 3 potential matches to compare side by side after the search")
+	browseFlag := flag.Bool("browse", false,
+		"Browse the potential matches after the search, marking responses and building a shortlist")
+	smtpServer := flag.String("smtp-server", "", "SMTP server host:port for sending email (i.e. smtp.gmail.com:587)")
+	smtpUser := flag.String("smtp-user", "",
+		"SMTP login, the password is the smtp-password credential")
+	notifiersFile := flag.String("notifiers", "notifiers.json",
+		"JSON file of the notifiers to send search events to")
+	skipUncontactable := flag.Bool("skip-uncontactable", false,
+		"Leave out potential matches with a team that has no valid contact email")
+	skipAsked := flag.Bool("skip-asked", false,
+		"Leave out potential matches with a team already contacted for another open swap")
+	orgFlags(flag.CommandLine)
+	presetFlag(flag.CommandLine)
+	flag.Parse()
+
+	// Runs nobody watches leave a log behind
+	if *logFile != "" {
+		l, err := openLog(*logFile)
+		if err != nil {
+			return err
+		}
+		eventLog.SetOutput(l)
+	}
+
+	output, err := outputWriter(*format)
+	if err != nil {
+		return err
+	}
+	slots, err := swap.ParseSlots(*days, *after, *before)
+	if err != nil {
+		return err
+	}
+	// A -divisions file replaces the rules, a -preset replaces divisions.json
+	if *divisionsFile != DIVISIONS_FILE {
+		if presetName != "" {
+			return errors.New("-divisions and -preset both choose the division rules, give only one")
+		}
+		if err := loadDivisions(*divisionsFile); err != nil {
+			return err
+		}
+	}
+	if err := applyPreset(); err != nil {
+		return err
+	}
+	var associations []association_t
+	if *orgs != "" {
+		if associations, err = parseAssociations(*orgs); err != nil {
+			return err
+		}
+	}
+	var rules associationRules
+	if *rulesFile != "" {
+		if rules, err = readAssociationRules(*rulesFile); err != nil {
+			return err
+		}
+	}
+
+	// location to save the schedule to
+	scheduleFile := "./schedule.csv"
+
+	// Set the cut off date for games to be considered
+	// This is today + 10 days
+	// Any games on or before this date will be ignored
+	cutOffDate := time.Now().AddDate(0, 0, 10)
+
+	// Auto download the schedule, or get it from a plugin
+	fetchRetries = *retries
+	fetchClient.Timeout = *timeout
+	scheduleTTL = *ttl
+	if *refresh {
+		scheduleTTL = 0
+	}
+	scheduleRecords, err := loadSchedule(*source, *pluginDir)
+	if err != nil {
+		return err
+	}
+	corrections, err := schedule.ReadCorrections(*correctionsFile)
+	if err != nil {
+		return err
+	}
+	for _, note := range schedule.Correct(scheduleRecords, corrections) {
+		fmt.Println("Correction ", note)
+	}
+	if len(associations) > 0 {
+		external, err := associationSchedules(associations)
+		if err != nil {
+			return err
+		}
+		scheduleRecords = append(scheduleRecords, external...)
+	}
+
+	// The schedule is searched in memory, saving it is only for reference
+	if *saveSchedule {
+		if err := writeSchedule(scheduleFile, scheduleRecords); err != nil {
+			return err
+		}
+	}
+	debug("Loaded %d games", len(scheduleRecords))
+	extra := schedule.ExtraColumns(scheduleRecords)
+	s.Games = schedule.Rows(scheduleRecords, extra)
+
+	// Columns can include the extra schedule columns so they are checked
+	// once the schedule is loaded
+	var selected []string
+	if *columns != "" {
+		selected, err = parseColumns(*columns, extra)
+		if err != nil {
+			return err
+		}
+	}
+	index := schedule.IndexByDate(s.Games)
+
+	// Get the team contacts
+	var contactList map[string]contacts.Contact
+	if *source != "" {
+		contactList, err = pluginContacts(*pluginDir, *source)
+		if err != nil {
+			return err
+		}
+	} else {
+		contactList, err = teamContacts(*saveContacts)
+		if err != nil {
+			return err
+		}
+	}
+	if *contactsFile != "" {
+		fileContacts, err := contacts.ReadFile(*contactsFile)
+		if err != nil {
+			return err
+		}
+		contactList, err = contacts.Merge(contactList, fileContacts, *contactPrecedence)
+		if err != nil {
+			return err
+		}
+	}
+	overrides, err := contacts.ReadOverrides(*overridesFile)
+	if err != nil {
+		return err
+	}
+	contacts.Override(contactList, overrides)
+
+	weights, err := swap.ReadWeights(*scoringFile)
+	if err != nil {
+		return err
+	}
+	tiers, err := swap.ParseTiers(*tiersFlag)
+	if err != nil {
+		return err
+	}
+	if _, err := parseCurfew(*curfew); err != nil {
+		return err
+	}
+	ranking, err := swap.ParseRanking(*rank)
+	if err != nil {
+		return err
+	}
+	if *rankResponsive {
+		ranking = append([]string{"responsive"}, ranking...)
+	}
+	if *rankDistance {
+		ranking = append([]string{"distance"}, ranking...)
+	}
+
+	// Distances to the arenas rule out or rank the far away matches
+	var distances map[string]float64
+	if *homeArena != "" {
+		arenas, err := readArenas(*arenasFile)
+		if err != nil {
+			return err
+		}
+		home, err := homeLocation(arenas, *homeArena)
+		if err != nil {
+			return err
+		}
+		var unknown []string
+		distances, unknown = venueDistances(arenas, home, s.Games)
+		if len(unknown) > 0 {
+			fmt.Println("No location for arenas: ", strings.Join(unknown, ", "))
+		}
+	} else if *maxKm > 0 || slices.Contains(ranking, "distance") {
+		return errors.New("-max-km and ranking by distance need -home-arena")
+	}
+
+	// Bulk rescheduling and matching work through lists of games instead of
+	// prompting
+	if *bulkFile != "" {
+		return bulkReschedule(s.Games, *bulkFile, *permitFile, cutOffDate, contactList, *redactContacts)
+	}
+	if *matchFiles != "" {
+		return matchNeeds(s.Games, *matchFiles, cutOffDate, contactList, *redactContacts)
+	}
+
+	// Get the ids of the games to swap, several can be searched at once
+	// This is use to find the two teams that are playing. Team names will be
+	// used to find dates to exclude
+	var ids []string
+	if *team != "" {
+		ids, err = teamGames(s.Games, *team, *dates)
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Print("Enter Id of game to swap, or several separated by commas (i.e. HLU1501): ")
+		ids = strings.FieldsFunc(readLine(), func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	if len(ids) == 0 {
+		return errors.New("no game to swap")
+	}
+	eventLog.Printf("searching for swaps for %s", strings.Join(ids, ", "))
+	if len(ids) > 1 && (*acceptId != "" || *packageId != "" || *compareIds != "") {
+		return errors.New("-accept, -package and -compare work with a single game")
+	}
+
+	// Teams can be unavailable for reasons the schedule doesn't show. The
+	// question is only asked along with the game ids, -team runs unattended
+	if *excludeTeams == "" && *team == "" {
+		fmt.Print("Other teams to exclude, comma separated (i.e. at a tournament): ")
+		*excludeTeams = readLine()
+	}
+
+	mailer = smtp_t{server: *smtpServer, user: *smtpUser, from: *emailFrom}
+	routes, err := readRoutes(*notifiersFile)
+	if err != nil {
+		return err
+	}
+
+	search := search_t{
+		games:             s.Games,
+		index:             index,
+		contacts:          contactList,
+		rules:             rules,
+		cutOffDate:        cutOffDate,
+		output:            output,
+		extra:             extra,
+		selected:          selected,
+		trackerFile:       *trackerFile,
+		packageId:         *packageId,
+		permitFile:        *permitFile,
+		acceptId:          *acceptId,
+		excludeTeams:      swap.ParseTeams(*excludeTeams),
+		household:         *household,
+		slots:             slots,
+		includeDeclined:   *includeDeclined,
+		skipUncontactable: *skipUncontactable,
+		skipAsked:         *skipAsked,
+		weights:           weights,
+		tiers:             tiers,
+		ranking:           ranking,
+		curfew:            *curfew,
+		distances:         distances,
+		maxKm:             *maxKm,
+		maxPerDivision:    *maxPerDivision,
+		maxPerTeam:        *maxPerTeam,
+		repeatDays:        *repeatDays,
+		summary:           *summary,
+		redact:            *redactContacts,
+		extraColumns:      *extraColumnsFlag,
+		freeMatrix:        *freeMatrixFlag,
+		emailDrafts:       *emailDrafts,
+		emailTemplate:     *emailTemplate,
+		emailFrom:         *emailFrom,
+		sendEmails:        *sendEmails || *dryRun,
+		dryRun:            *dryRun,
+		routes:            routes,
+	}
+	var reports []report_t
+	for _, id := range ids {
+		report, ok, err := search.game(id)
+		if err != nil {
+			return err
+		}
+		if ok {
+			reports = append(reports, report)
+		}
+	}
+
+	// The matches for several games are also listed together, once each
+	if len(ids) > 1 {
+		if err := writeCombined(reports, COMBINED_FILE); err != nil {
+			return err
+		}
+	}
+
+	if *compareIds != "" && len(reports) > 0 {
+		fmt.Println()
+		if err := reports[0].compare(os.Stdout, strings.Split(*compareIds, ",")); err != nil {
+			return err
+		}
+	}
+	if *browseFlag && len(reports) > 0 {
+		return browse(reports, *trackerFile, output)
+	}
+	fmt.Println("Press enter to contine")
+	fmt.Scanln()
+	return nil
+}
+
+// Structure to hold the settings for searching for swaps for a game
+type search_t struct {
+	games      [][]string                  // all games in the schedule
+	index      schedule.Index              // all games bucketed by date
+	contacts   map[string]contacts.Contact // team contacts by team name
+	rules      associationRules            // other associations' compatible divisions
+	cutOffDate time.Time                   // games before this date are ignored
+	output     OutputWriter                // writer for the report
+	extra      []string                    // names of the extra schedule columns
+	selected   []string                    // keys of the columns to output
+
+	trackerFile       string
+	packageId         string
+	permitFile        string
+	acceptId          string
+	excludeTeams      []string
+	household         string
+	slots             swap.Slots // days and hours the team can play
+	includeDeclined   bool
+	skipUncontactable bool
+	skipAsked         bool
+	weights           swap.Weights       // weights for scoring the potential matches
+	tiers             swap.Tiers         // thresholds for the strong and possible matches
+	ranking           []string           // rankers ordering the potential matches, first decides
+	curfew            string             // latest time games can end, HH:MM
+	distances         map[string]float64 // km to the arenas by name, nil without -home-arena
+	maxKm             float64
+	maxPerDivision    int
+	maxPerTeam        int
+	repeatDays        int
+	summary           bool
+	redact            bool
+	extraColumns      bool
+	freeMatrix        bool
+	emailDrafts       bool
+	emailTemplate     string
+	emailFrom         string
+	sendEmails        bool
+	dryRun            bool
+	routes            []route_t
+}
+
+/*
+Search for swaps for a game and write the report of potential matches.
+Returns the report, or false if the search didn't produce one (i.e. the game
+is before the cut off date or a swap was accepted).
+*/
+func (search search_t) game(gameId string) (report_t, bool, error) {
+	// create a debugger object
+	var debug = debuggo.Debug("main")
+
+	// Use the game id to find the division and teams needing a swap
+	// This will be used to find the dates and teams to exclude
+	// when searching for potential matches
+	s, division, err := swap.New(search.games, search.index, gameId)
+	if err != nil {
+		return report_t{}, false, err
+	}
+	index := search.index
+	contactList := search.contacts
+	cutOffDate := search.cutOffDate
+	fmt.Println("Game date: ", s.Date)
+	fmt.Println("Home team: ", s.Home)
+	fmt.Println("Away team: ", s.Away)
+	fmt.Println("Your division: ", division.Name)
+	fmt.Println("Searching for swaps with the following divisions: ", division.Swaps)
+
+	// A game with a score has been played, whatever its date
+	if schedule.Scored(s.Game) {
+		fmt.Println("Game has a score recorded, it has already been played")
+		return report_t{}, false, nil
+	}
+
+	// Check that the game date is not before the cut off date
+	// If it is then there is no point in continuing
+	gameDate, err := time.Parse(schedule.DATE_FORMAT, s.Date)
+	if err != nil {
+		return report_t{}, false, err
+	}
+	if gameDate.Before(cutOffDate) {
+		fmt.Println("Game date is before cut off date of ", cutOffDate.Format(schedule.DATE_FORMAT))
+		fmt.Println("No point in continuing")
+		return report_t{}, false, nil
+	}
+
+	// compile regex to check if division is acceptable for swaps
+	swappableRe, err := regexp.Compile(swappableExpr(division, search.rules))
+	if err != nil {
+		return report_t{}, false, err
+	}
+
+	// Open ice slots are an alternative to swapping and are checked against
+	// the full schedule
+	if search.permitFile != "" {
+		if err := writeOpenSlots(s, search.permitFile, cutOffDate); err != nil {
+			return report_t{}, false, err
+		}
+	}
+
+	// Reduce the schedule to the potential matches
+	allGames := slices.Clone(s.Games)
+	s.FindCandidates(swappableRe, cutOffDate)
+
+	if len(search.excludeTeams) > 0 {
+		fmt.Println("Excluding teams: ", strings.Join(search.excludeTeams, ", "))
+		s.RemoveTeams(search.excludeTeams, "excluded team")
+		s.AddStep("after excluded teams")
+	}
+
+	// Only the slots the team could take are worth asking about
+	if search.slots.Limited() {
+		s.RemoveOutsideSlots(search.slots)
+		s.AddStep("after preferred slots")
+	}
+
+	// Families with children on other teams can't be at two games at once
+	if search.household != "" {
+		s.RemoveHouseholdDates(search.household)
+		s.AddStep("after household conflicts")
+	}
+
+	// Carry forward the responses from the last report for the game and
+	// leave out the teams that declined. Responses are kept in the tracker
+	// as declined games are no longer in the next report.
+	reported, err := readResponses(s.GameID + ".csv")
+	if err != nil {
+		return report_t{}, false, err
+	}
+	tracker, err := updateTracker(search.trackerFile, func(tracker *tracker_t) {
+		if len(reported) > 0 {
+			tracker.setResponses(s.GameID, reported)
+		}
+		tracker.addSearch(s.GameID)
+	})
+	if err != nil {
+		return report_t{}, false, err
+	}
+	responses := tracker.Responses[s.GameID]
+	if declined := declinedTeams(responses, index); len(declined) > 0 && !search.includeDeclined {
+		fmt.Println("Excluding teams that declined: ", strings.Join(declined, ", "))
+		s.RemoveTeams(declined, "declined")
+		s.AddStep("after declined teams")
+	}
+	if declined := tracker.declinedGames(); len(declined) > 0 && !search.includeDeclined {
+		s.RemoveGames(declined, "declined for all swaps")
+		s.AddStep("after declined games")
+	}
+
+	// The team can't play on its blackout dates
+	if len(tracker.Blackouts) > 0 {
+		before := len(s.Games)
+		s.RemoveDates(tracker.Blackouts, "blackout date")
+		if before > len(s.Games) {
+			s.AddStep("after blackout dates")
+		}
+	}
+
+	// Snoozed matches are hidden until their date comes
+	if snoozed := tracker.snoozed(s.GameID, time.Now().Format(schedule.DATE_FORMAT)); len(snoozed) > 0 {
+		before := len(s.Games)
+		s.RemoveGames(snoozed, "snoozed")
+		if hidden := before - len(s.Games); hidden > 0 {
+			fmt.Printf("Hiding %d snoozed matches\n", hidden)
+			s.AddStep("after snoozed")
+		}
+	}
+
+	// Teams already asked about another of the team's swaps shouldn't be
+	// asked again until that one is settled
+	asked := otherSwaps(tracker, search.games, s.GameID, time.Now().Format(schedule.DATE_FORMAT))
+	if teams := contactedTeams(asked); len(teams) > 0 && search.skipAsked {
+		fmt.Println("Excluding teams asked for other swaps: ", strings.Join(teams, ", "))
+		s.RemoveTeams(teams, "asked about another swap")
+		s.AddStep("after asked for other swaps")
+	}
+
+	// Teams without a usable contact have to be reached some other way
+	if problems := contacts.Problems(contactList, s.Games); len(problems) > 0 {
+		fmt.Println("Teams with contact problems:")
+		for _, problem := range problems {
+			fmt.Printf("  %s: %s\n", problem[0], problem[1])
+		}
+		if search.skipUncontactable {
+			s.RemoveUncontactable(contactList)
+			s.AddStep("after uncontactable")
+		}
+	}
+
+	// Arenas too far to travel to aren't worth the swap
+	if search.maxKm > 0 {
+		s.RemoveFarVenues(search.distances, search.maxKm)
+		s.AddStep(fmt.Sprintf("within %g km", search.maxKm))
+	}
+
+	// Two-game package swaps are made from the same potential matches,
+	// checked against the full schedule, and reported separately
+	if search.packageId != "" {
+		return report_t{}, false, writePackages(s, allGames, search.packageId, cutOffDate, contactList, search.redact)
+	}
+
+	// The matches that change the least for both teams, the nearest or the
+	// teams that answer are listed first, depending on who reads the list
+	scores := s.Scores(search.weights, tracker.ShortStaffed)
+	rates := responseRates(tracker, index)
+	replyTimes := replyDays(tracker, index)
+	s.Rank(search.ranking, swap.RankData{
+		Scores:    scores,
+		Distances: search.distances,
+		Rates:     rates,
+		ReplyDays: replyTimes,
+	})
+
+	// Dates the team is short-staffed are the last resort
+	warnings := s.DeprioritizeDates(tracker.ShortStaffed)
+
+	// A team with a game on its new date makes the swap fall through, the
+	// search only rules those out one way for some games
+	conflicts := s.CheckBothWays()
+	if len(conflicts) > 0 {
+		fmt.Printf("%d potential matches have a schedule conflict, listed last\n", len(conflicts))
+	}
+	for id, conflict := range conflicts {
+		warnings[id] = append(warnings[id], conflict...)
+	}
+
+	// Keep the list to a manageable number of options
+	if search.maxPerDivision > 0 || search.maxPerTeam > 0 {
+		s.CapCandidates(search.maxPerDivision, search.maxPerTeam)
+		s.AddStep("after caps")
+	}
+
+	// An accepted swap is written as a change request to copy into the league
+	// system
+	if search.acceptId != "" {
+		if err := writeChangeRequest(s, search.acceptId); err != nil {
+			return report_t{}, false, err
+		}
+		_, err := updateTracker(search.trackerFile, func(tracker *tracker_t) {
+			tracker.accept(s.GameID, search.acceptId)
+		})
+		if err != nil {
+			return report_t{}, false, err
+		}
+		notify(search.routes, event_t{Name: EVENT_ACCEPTED, GameID: s.GameID,
+			Text: fmt.Sprintf("Swap accepted: %s on %s <-> %s", s.GameID, s.Date, search.acceptId)})
+		return report_t{}, false, nil
+	}
+
+	// Open file to write possible game swaps to
+	fileName := s.GameID + search.output.Ext()
+	debug("Creating output file: %s", fileName)
+	outFile, err := os.Create(fileName)
+	if err != nil {
+		return report_t{}, false, err
+	}
+	defer outFile.Close()
+
+	tiers := search.tiers.Assign(scores, search.weights)
+	for _, g := range s.Games {
+		fmt.Printf("%-9s %s\n", tiers[g[schedule.GAMEID]], strings.Join(g, ","))
+	}
+
+	if notes := tracker.Notes[s.GameID][""]; len(notes) > 0 {
+		fmt.Println("Notes: ", formatNotes(notes))
+	}
+
+	report := report_t{swap: s, division: division, contacts: contactList,
+		notes: tracker.Notes[s.GameID], responses: responses}
+	report.summary = search.summary
+	report.redact = search.redact
+	if search.repeatDays > 0 {
+		for id, repeats := range repeatWarnings(s, search.repeatDays) {
+			warnings[id] = append(warnings[id], repeats...)
+		}
+	}
+	for id, overlaps := range overlapWarnings(s, asked) {
+		warnings[id] = append(warnings[id], overlaps...)
+	}
+	report.warnings = warnings
+	report.scores = scores
+	report.tiers = tiers
+	report.distances = search.distances
+	report.rates = rates
+	report.replyDays = replyTimes
+	report.curfew = search.curfew
+	if search.extraColumns || len(search.selected) > 0 {
+		report.extra = search.extra
+		report.columns = search.selected
+	}
+	if err := search.output.Write(outFile, report); err != nil {
+		return report_t{}, false, err
+	}
+	// The CSV report stays a header and a row per match, the summary has its
+	// own file
+	if _, ok := search.output.(csvOutput); ok && search.summary {
+		if err := writeSummaryCSV(report, s.GameID+"-summary.csv"); err != nil {
+			return report_t{}, false, err
+		}
+	}
+
+	if search.summary {
+		printSummary(report)
+	}
+	printFunnel(s)
+	fmt.Printf("Recorded %d potential matches to %s\n", len(s.Games), fileName)
+	eventLog.Printf("%s: %d potential matches: %s", s.GameID, len(s.Games), funnelText(s))
+	var proposed []string
+	for _, g := range s.Games {
+		proposed = append(proposed, g[schedule.GAMEID])
+	}
+	_, err = updateTracker(search.trackerFile, func(tracker *tracker_t) {
+		tracker.propose(s.GameID, proposed)
+	})
+	if err != nil {
+		return report_t{}, false, err
+	}
+	if len(s.Games) > 0 {
+		notify(search.routes, event_t{Name: EVENT_CANDIDATES, GameID: s.GameID,
+			Text: fmt.Sprintf("%d potential matches for %s on %s (%s vs %s)",
+				len(s.Games), s.GameID, s.Date, s.Home, s.Away)})
+	} else {
+		notify(search.routes, event_t{Name: EVENT_NO_SWAPS, GameID: s.GameID,
+			Text: fmt.Sprintf("No potential matches for %s on %s (%s vs %s)",
+				s.GameID, s.Date, s.Home, s.Away)})
+	}
+	if search.freeMatrix {
+		if err := writeFreeMatrix(s, cutOffDate); err != nil {
+			return report_t{}, false, err
+		}
+	}
+
+	if search.emailDrafts {
+		if err := writeEmailDrafts(report, search.emailTemplate, search.emailFrom); err != nil {
+			return report_t{}, false, err
+		}
+	}
+
+	if search.sendEmails {
+		sent, err := sendSwapRequests(report, search.emailTemplate, search.dryRun)
+		if !search.dryRun {
+			eventLog.Printf("%s: sent %d swap request emails", s.GameID, len(sent))
+		}
+		if len(sent) > 0 {
+			contacted := make(map[string]string)
+			for _, id := range sent {
+				contacted[id] = "Contacted"
+			}
+			_, err := updateTracker(search.trackerFile, func(tracker *tracker_t) {
+				tracker.setResponses(s.GameID, contacted)
+			})
+			if err != nil {
+				return report_t{}, false, err
+			}
+		}
+		if err != nil {
+			return report_t{}, false, err
+		}
+	}
+
+	// Suggest an exhibition game so the ice isn't wasted
+	if len(s.Games) == 0 {
+		if err := writeExhibitions(allGames, index, s, swappableRe, contactList, search.redact); err != nil {
+			return report_t{}, false, err
+		}
+	}
+	return report, true, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Searching several games at once
+
+ A team often needs swaps for more than one game (i.e. a tournament weekend
+ and a school trip). The games can be entered together, or all of a team's
+ games in a date range searched, and each gets its own report as usual. The
+ potential matches for all of them are also written to one combined report
+ that lists each match once with the games it could be swapped with.
+*/
+
+// File the combined report of several searches is written to
+const COMBINED_FILE = "combined.csv"
+
+/*
+Return the ids of the team's games between the dates, a range written as
+FROM..TO with either end optional. All of the team's games are returned if
+the range is empty.
+*/
+func teamGames(games [][]string, team, dates string) ([]string, error) {
+	from, to, _ := strings.Cut(dates, "..")
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(schedule.DATE_FORMAT, date); err != nil {
+			return nil, fmt.Errorf("date range %s is not YYYY-MM-DD..YYYY-MM-DD", dates)
+		}
+	}
+
+	team = swap.NormalizeTeam(team)
+	var ids []string
+	for _, game := range games {
+		if swap.NormalizeTeam(game[schedule.HOMETEAM]) != team && swap.NormalizeTeam(game[schedule.AWAYTEAM]) != team {
+			continue
+		}
+		if (from != "" && game[schedule.DATE] < from) || (to != "" && game[schedule.DATE] > to) {
+			continue
+		}
+		ids = append(ids, game[schedule.GAMEID])
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no games found for %s in %s", team, cmp.Or(dates, "the schedule"))
+	}
+	return ids, nil
+}
+
+/*
+Write the potential matches from several reports to a CSV file, each match
+once in date order with the ids of the games it could be swapped with. The
+contacts are for the teams in the match.
+*/
+func writeCombined(reports []report_t, fileName string) error {
+	swapsWith := make(map[string][]string)
+	var matches [][]string
+	for _, report := range reports {
+		for _, g := range report.swap.Games {
+			if _, seen := swapsWith[g[schedule.GAMEID]]; !seen {
+				matches = append(matches, g)
+			}
+			swapsWith[g[schedule.GAMEID]] = append(swapsWith[g[schedule.GAMEID]], report.swap.GameID)
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b []string) int {
+		return cmp.Or(cmp.Compare(a[schedule.DATE], b[schedule.DATE]), cmp.Compare(a[schedule.TIME], b[schedule.TIME]))
+	})
+
+	csvFile, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	header := append([]string{"Swap With"}, reportHeader[:schedule.AWAYTEAM+1]...)
+	writer.Write(append(header, "Contacts"))
+	// The reports all share the contacts and settings, matches only come
+	// from reports so there is at least one when there are matches
+	for _, g := range matches {
+		emails := contactsFor(reports[0].redact)
+		row := append([]string{strings.Join(swapsWith[g[schedule.GAMEID]], ";")}, g[:schedule.AWAYTEAM+1]...)
+		writer.Write(append(row, emails(reports[0].contacts, g[schedule.HOMETEAM], g[schedule.AWAYTEAM])))
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recorded %d potential matches for %d games to %s\n", len(matches), len(reports), fileName)
+	return nil
+}
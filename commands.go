@@ -0,0 +1,231 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Sub-commands
+
+ Sub-commands do jobs other than searching for swaps, like maintaining the
+ tracker or exploring the schedule, i.e.
+
+   go-scheduler note HLU1501 HLU1622 "coach prefers Sunday"
+   go-scheduler decline -game HLU1501 -reason tournament HLU1622
+   go-scheduler snooze -until 2025-03-10 HLU1622
+   go-scheduler purge -days 180
+   go-scheduler short 2025-02-15 "goalie away"
+   go-scheduler blackout 2025-03-07..2025-03-09 "tournament"
+   go-scheduler query
+   go-scheduler analytics
+   go-scheduler kickoff "GCTCOUGARS1"
+   go-scheduler backup
+   go-scheduler restore go-scheduler-backup-2025-01-12.zip
+   go-scheduler import
+   go-scheduler contacts
+   go-scheduler credentials set passphrase
+   go-scheduler scenario scenarios/*.json
+   go-scheduler logs -n 50
+
+ Each one parses its own flags from the arguments following its name.
+*/
+
+// Sub-commands by name
+var commands = map[string]func(args []string) error{
+	"analytics":   analyticsCommand,
+	"backup":      backupCommand,
+	"blackout":    blackoutCommand,
+	"contacts":    contactsCommand,
+	"credentials": credentialsCommand,
+	"decline":     declineCommand,
+	"import":      importCommand,
+	"kickoff":     kickoffCommand,
+	"logs":        logsCommand,
+	"note":        noteCommand,
+	"purge":       purgeCommand,
+	"query":       queryCommand,
+	"restore":     restoreCommand,
+	"scenario":    scenarioCommand,
+	"short":       shortCommand,
+	"snooze":      snoozeCommand,
+}
+
+/*
+Add a note to a swap game or one of its potential matches.
+
+	note [-tracker file] GAMEID [MATCHID] TEXT
+*/
+func noteCommand(args []string) error {
+	flags := flag.NewFlagSet("note", flag.ExitOnError)
+	trackerFile := flags.String("tracker", "tracker.json", "File the swap tracker is stored in")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler note [options] GAMEID [MATCHID] TEXT")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var gameId, matchId, text string
+	switch flags.NArg() {
+	case 2:
+		gameId, text = flags.Arg(0), flags.Arg(1)
+	case 3:
+		gameId, matchId, text = flags.Arg(0), flags.Arg(1), flags.Arg(2)
+	default:
+		flags.Usage()
+		return fmt.Errorf("note needs a game id and the text of the note")
+	}
+
+	_, err := updateTracker(*trackerFile, func(tracker *tracker_t) {
+		tracker.addNote(gameId, matchId, strings.TrimSpace(text))
+	})
+	return err
+}
+
+/*
+Record that a potential match declined a swap.
+
+	decline [-tracker file] [-game GAMEID] [-reason code] MATCHID
+
+Without -game the match is declined for every swap and the game is left out
+of all searches. With -game only searches for that swap game are affected,
+and the match's teams are left out of them.
+*/
+func declineCommand(args []string) error {
+	flags := flag.NewFlagSet("decline", flag.ExitOnError)
+	trackerFile := flags.String("tracker", "tracker.json", "File the swap tracker is stored in")
+	gameId := flags.String("game", "", "Id of the swap game the match declined")
+	reason := flags.String("reason", "other",
+		"Reason for declining ("+strings.Join(declineReasons, ", ")+")")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler decline [options] MATCHID")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("decline needs the id of the game that declined")
+	}
+	code := strings.ToLower(*reason)
+	if !slices.Contains(declineReasons, code) {
+		return fmt.Errorf("unknown reason %s (available: %s)", *reason,
+			strings.Join(declineReasons, ", "))
+	}
+
+	_, err := updateTracker(*trackerFile, func(tracker *tracker_t) {
+		tracker.addDecline(*gameId, flags.Arg(0), code)
+	})
+	return err
+}
+
+/*
+Hide a potential match from reports until a date.
+
+	snooze [-tracker file] [-game GAMEID] -until DATE MATCHID
+
+Without -game the match is hidden from every search.
+*/
+func snoozeCommand(args []string) error {
+	flags := flag.NewFlagSet("snooze", flag.ExitOnError)
+	trackerFile := flags.String("tracker", "tracker.json", "File the swap tracker is stored in")
+	gameId := flags.String("game", "", "Id of the swap game to hide the match from")
+	until := flags.String("until", "", "Date the match is shown again (i.e. 2025-03-10)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler snooze [options] -until DATE MATCHID")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || *until == "" {
+		flags.Usage()
+		return fmt.Errorf("snooze needs the id of the game and a date")
+	}
+	if _, err := time.Parse(schedule.DATE_FORMAT, *until); err != nil {
+		return fmt.Errorf("invalid date %s, expected YYYY-MM-DD", *until)
+	}
+
+	_, err := updateTracker(*trackerFile, func(tracker *tracker_t) {
+		tracker.addSnooze(*gameId, flags.Arg(0), *until)
+	})
+	return err
+}
+
+/*
+Tag a date the team is short-staffed, so potential matches on it are listed
+last instead of being left out.
+
+	short [-tracker file] DATE [REASON]
+*/
+func shortCommand(args []string) error {
+	flags := flag.NewFlagSet("short", flag.ExitOnError)
+	trackerFile := flags.String("tracker", "tracker.json", "File the swap tracker is stored in")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler short [options] DATE [REASON]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return fmt.Errorf("short needs a date")
+	}
+	date := flags.Arg(0)
+	if _, err := time.Parse(schedule.DATE_FORMAT, date); err != nil {
+		return fmt.Errorf("invalid date %s, expected YYYY-MM-DD", date)
+	}
+
+	_, err := updateTracker(*trackerFile, func(tracker *tracker_t) {
+		tracker.addShortStaffed(date, flags.Arg(1))
+	})
+	return err
+}
+
+/*
+Black out dates the team can't play, so potential matches on them are left
+out of searches. A range of dates is written as FROM..TO.
+
+	blackout [-tracker file] [-remove] DATE[..DATE] [REASON]
+*/
+func blackoutCommand(args []string) error {
+	flags := flag.NewFlagSet("blackout", flag.ExitOnError)
+	trackerFile := flags.String("tracker", "tracker.json", "File the swap tracker is stored in")
+	remove := flags.Bool("remove", false, "Clear the dates instead, i.e. the tournament was cancelled")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler blackout [options] DATE[..DATE] [REASON]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return fmt.Errorf("blackout needs a date or range of dates")
+	}
+	from, to, isRange := strings.Cut(flags.Arg(0), "..")
+	if !isRange {
+		to = from
+	}
+	first, err := time.Parse(schedule.DATE_FORMAT, from)
+	if err != nil {
+		return fmt.Errorf("invalid date %s, expected YYYY-MM-DD", from)
+	}
+	last, err := time.Parse(schedule.DATE_FORMAT, to)
+	if err != nil {
+		return fmt.Errorf("invalid date %s, expected YYYY-MM-DD", to)
+	}
+	if last.Before(first) {
+		return fmt.Errorf("range %s ends before it starts", flags.Arg(0))
+	}
+
+	_, err = updateTracker(*trackerFile, func(tracker *tracker_t) {
+		for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+			tracker.setBlackout(d.Format(schedule.DATE_FORMAT), flags.Arg(1), *remove)
+		}
+	})
+	return err
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Comparing potential matches
+
+ The last choice is usually between two or three matches. Side by side they
+ show what each one changes: how far the game moves, the distance to the
+ arena, whether the game ends before the team's curfew, how often the other
+ teams reply and which division rule allows the swap, i.e.
+
+   go-scheduler -compare HLU1622,HLU1630
+
+ or cmp 1 3 while browsing the matches.
+*/
+
+/*
+Parse a curfew as HH:MM. An empty curfew is no curfew.
+*/
+func parseCurfew(clock string) (time.Duration, error) {
+	if clock == "" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, fmt.Errorf("invalid curfew %q, expected HH:MM", clock)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+/*
+Return when a game ends and whether it ends by the curfew.
+*/
+func (report report_t) curfewText(game []string) string {
+	start, err := schedule.Start(game)
+	if err != nil || strings.TrimSpace(game[schedule.TIME]) == "" {
+		return "no start time"
+	}
+	end := start.Add(GAME_LENGTH)
+	text := end.Format("15:04")
+	if report.curfew == "" {
+		return text
+	}
+	curfew, _ := parseCurfew(report.curfew)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	if end.After(day.Add(curfew)) {
+		return text + ", after the " + report.curfew + " curfew"
+	}
+	return text + ", by the " + report.curfew + " curfew"
+}
+
+/*
+Return how often and how quickly the teams of a game have replied to
+contacts.
+*/
+func (report report_t) responsiveness(game []string) string {
+	var teams []string
+	for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+		if rate, ok := report.rates[swap.NormalizeTeam(team)]; ok {
+			text := fmt.Sprintf("%s %.0f%%", team, rate*100)
+			if days, ok := report.replyDays[swap.NormalizeTeam(team)]; ok {
+				text += fmt.Sprintf(" in %.1f days", days)
+			}
+			teams = append(teams, text)
+		} else {
+			teams = append(teams, team+" not contacted yet")
+		}
+	}
+	return strings.Join(teams, ", ")
+}
+
+/*
+Return the division rule that allows the swap with a game.
+*/
+func (report report_t) ruleText(game []string) string {
+	if game[schedule.DIVISION] == report.swap.Game[schedule.DIVISION] {
+		return "same division"
+	}
+	return game[schedule.DIVISION] + " by " + report.division.Swaps
+}
+
+/*
+Return how far a game is from the date of the swap game.
+*/
+func (report report_t) dateDelta(game []string) string {
+	from, errFrom := time.Parse(schedule.DATE_FORMAT, report.swap.Date)
+	to, errTo := time.Parse(schedule.DATE_FORMAT, game[schedule.DATE])
+	if errFrom != nil || errTo != nil {
+		return ""
+	}
+	return fmt.Sprintf("%+d days", int(to.Sub(from).Hours()/24))
+}
+
+/*
+Write two or three potential matches side by side.
+*/
+func (report report_t) compare(w io.Writer, ids []string) error {
+	if len(ids) < 2 || len(ids) > 3 {
+		return fmt.Errorf("compare needs 2 or 3 potential matches, not %d", len(ids))
+	}
+	var games [][]string
+	for _, id := range ids {
+		idx := slices.IndexFunc(report.swap.Games, func(game []string) bool {
+			return strings.EqualFold(game[schedule.GAMEID], id)
+		})
+		if idx < 0 {
+			return fmt.Errorf("%s isn't a potential match for %s", id, report.swap.GameID)
+		}
+		games = append(games, report.swap.Games[idx])
+	}
+
+	rows := []struct {
+		label string
+		value func(game []string) string
+	}{
+		{"Game", func(g []string) string { return g[schedule.GAMEID] }},
+		{"Division", func(g []string) string { return g[schedule.DIVISION] }},
+		{"Date", func(g []string) string { return g[schedule.DATE] + " " + g[schedule.TIME] }},
+		{"Moves", report.dateDelta},
+		{"Arena", func(g []string) string { return g[schedule.VENUE] }},
+		{"Distance", func(g []string) string {
+			if report.distances == nil {
+				return "no -home-arena"
+			}
+			if km, ok := report.distances[g[schedule.VENUE]]; ok {
+				return strconv.FormatFloat(km, 'f', 1, 64) + " km"
+			}
+			return "unknown"
+		}},
+		{"Ends", report.curfewText},
+		{"Teams", func(g []string) string { return g[schedule.HOMETEAM] + " vs " + g[schedule.AWAYTEAM] }},
+		{"Replies", report.responsiveness},
+		{"Rule", report.ruleText},
+		{"Score", func(g []string) string {
+			if score, ok := report.scores[g[schedule.GAMEID]]; ok {
+				return strconv.FormatFloat(score, 'f', 1, 64)
+			}
+			return ""
+		}},
+		{"Response", func(g []string) string { return report.responses[g[schedule.GAMEID]] }},
+		{"Notes", func(g []string) string { return formatNotes(report.notes[g[schedule.GAMEID]]) }},
+		{"Warnings", func(g []string) string { return strings.Join(report.warnings[g[schedule.GAMEID]], "; ") }},
+	}
+
+	fmt.Fprintf(w, "%s on %s, %s vs %s\n", report.swap.GameID, report.swap.Date, report.swap.Home, report.swap.Away)
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, row := range rows {
+		cells := []string{row.label}
+		for _, game := range games {
+			cells = append(cells, row.value(game))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
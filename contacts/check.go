@@ -0,0 +1,78 @@
+package contacts
+
+import (
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Contact checks
+
+ The contacts feed is kept by the league and often has teams missing or
+ emails mistyped. The teams in the potential matches are checked so the
+ manager knows which teams have to be reached some other way.
+*/
+
+/*
+Return the problem with a team's contact, or an empty string if there is
+none, and whether the team has at least one valid email to reach them by.
+*/
+func Problem(contacts map[string]Contact, team string) (string, bool) {
+	contact, ok := contacts[team]
+	if !ok {
+		return "no contact entry", false
+	}
+	var problems []string
+	valid := 0
+	for _, email := range append([]string{contact.CoachEmail, contact.ManagerEmail}, contact.Others...) {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+		if ValidEmail(email) {
+			valid++
+		} else {
+			problems = append(problems, "invalid email "+email)
+		}
+	}
+	if valid == 0 && len(problems) == 0 {
+		problems = append(problems, "no email")
+	}
+	return strings.Join(problems, ", "), valid > 0
+}
+
+/*
+Check an email is a single bare address with a domain containing a dot.
+*/
+func ValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return false
+	}
+	_, domain, _ := strings.Cut(email, "@")
+	return strings.Contains(strings.Trim(domain, "."), ".")
+}
+
+/*
+Return the problems with the contacts of the teams in the games, as team and
+problem rows sorted by team.
+*/
+func Problems(contacts map[string]Contact, games [][]string) [][]string {
+	var teams []string
+	for _, game := range games {
+		teams = append(teams, game[schedule.HOMETEAM], game[schedule.AWAYTEAM])
+	}
+	slices.Sort(teams)
+	teams = slices.Compact(teams)
+
+	var rows [][]string
+	for _, team := range teams {
+		if problem, _ := Problem(contacts, team); problem != "" {
+			rows = append(rows, []string{team, problem})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,142 @@
+/*
+Package contacts gets the team contacts (coach and manager emails) from Total
+Team Management, combines them with local contact files and checks them.
+*/
+package contacts
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+// District and association ids of the association's team contacts
+const (
+	DISTRICT    = "district9"
+	ASSOCIATION = "GHA"
+)
+
+// URL of the team contacts export
+var URL = TeamsURL(DISTRICT, ASSOCIATION)
+
+/*
+Return the URL of the team contacts export for an association in a district.
+*/
+func TeamsURL(district, association string) string {
+	return "https://api.off-iceoffice.ca/ooAPI/v1/schedules/teams/?orgID=" +
+		url.QueryEscape(district) + "&id=" + url.QueryEscape(association)
+}
+
+// Structure to hold TTM API response for team contacts
+type Contact struct {
+	ID           string `json:"id"`
+	Division     string `json:"divisionName"`
+	Category     string `json:"categoryName"`
+	Team         string `json:"teamName"`
+	Coach        string `json:"coachName"`
+	CoachEmail   string `json:"coachEmail"`
+	Manager      string `json:"managerName"`
+	ManagerEmail string `json:"managerEmail"`
+	Type         string `json:"type"`
+
+	// Other emails for the team from the supplemental contacts file
+	Others []string `json:"others,omitempty"`
+}
+
+/*
+Decode the contacts in a TTM response body.
+*/
+func Decode(body io.Reader) ([]Contact, error) {
+	var contacts []Contact
+	err := schedule.Decode(body, func(contact Contact) error {
+		contacts = append(contacts, contact)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the contacts: %w", err)
+	}
+	return contacts, nil
+}
+
+/*
+Download the team contacts from TTM.
+*/
+func Download() ([]Contact, error) {
+	resp, err := http.Get(URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", URL, resp.Status)
+	}
+	return Decode(resp.Body)
+}
+
+/*
+Map the contacts by team name.
+*/
+func Map(contacts []Contact) map[string]Contact {
+	contactMap := make(map[string]Contact)
+	for _, contact := range contacts {
+		contactMap[contact.Team] = contact
+	}
+	return contactMap
+}
+
+/*
+Return the coach, manager and other emails for the teams separated by
+semi-colons.
+*/
+func Emails(contacts map[string]Contact, teams ...string) string {
+	var emails []string
+	for _, team := range teams {
+		emails = append(emails, contacts[team].CoachEmail, contacts[team].ManagerEmail)
+		emails = append(emails, contacts[team].Others...)
+	}
+	return strings.Join(emails, ";")
+}
+
+/*
+Return the valid emails for the teams without duplicates, for addressing an
+email.
+*/
+func Addresses(contacts map[string]Contact, teams ...string) []string {
+	var addresses []string
+	for _, team := range teams {
+		contact := contacts[team]
+		for _, email := range append([]string{contact.CoachEmail, contact.ManagerEmail}, contact.Others...) {
+			email = strings.TrimSpace(email)
+			if ValidEmail(email) && !slices.Contains(addresses, email) {
+				addresses = append(addresses, email)
+			}
+		}
+	}
+	return addresses
+}
+
+/*
+Return labels for the teams' contacts (i.e. "Ducks coach") separated by
+semi-colons, for reports that are shared publicly.
+*/
+func Labels(contacts map[string]Contact, teams ...string) string {
+	var labels []string
+	for _, team := range teams {
+		contact := contacts[team]
+		if contact.CoachEmail != "" {
+			labels = append(labels, team+" coach")
+		}
+		if contact.ManagerEmail != "" {
+			labels = append(labels, team+" manager")
+		}
+		for i := range contact.Others {
+			labels = append(labels, fmt.Sprintf("%s contact %d", team, i+1))
+		}
+	}
+	return strings.Join(labels, ";")
+}
@@ -0,0 +1,161 @@
+package contacts
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+)
+
+/*
+ Supplemental contacts
+
+ The contacts feed only has a coach and manager for each team. A local CSV
+ file can add other people to reach (i.e. association scheduler, convenor,
+ alternate manager) and fill in the gaps in the feed. The file has a header
+ row with Team, Role, Name and Email columns. Coach and manager roles fill the
+ team's coach and manager, any other role is added to the team's other emails.
+*/
+
+// Which source wins when the feed and the file both have a coach or manager
+var Precedences = []string{"feed", "file"}
+
+/*
+Read the supplemental contacts file into contacts by team name.
+*/
+func ReadFile(path string) (map[string]Contact, error) {
+	fi, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fi.Close()
+
+	rows, err := csv.NewReader(fi).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	col := make(map[string]int)
+	for i, name := range rows[0] {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"team", "role", "name", "email"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("%s has no %s column", path, name)
+		}
+	}
+
+	contacts := make(map[string]Contact)
+	for _, row := range rows[1:] {
+		team := strings.TrimSpace(row[col["team"]])
+		if team == "" {
+			continue
+		}
+		name := strings.TrimSpace(row[col["name"]])
+		email := strings.TrimSpace(row[col["email"]])
+
+		contact := contacts[team]
+		contact.Team = team
+		switch strings.ToLower(strings.TrimSpace(row[col["role"]])) {
+		case "coach":
+			contact.Coach, contact.CoachEmail = name, email
+		case "manager":
+			contact.Manager, contact.ManagerEmail = name, email
+		default:
+			contact.Others = append(contact.Others, email)
+		}
+		contacts[team] = contact
+	}
+	return contacts, nil
+}
+
+/*
+Merge the supplemental contacts into the contacts from the feed. With "feed"
+precedence the file only fills in a blank coach or manager, with "file"
+precedence the file replaces them. Other emails are always added.
+*/
+func Merge(feed, file map[string]Contact, precedence string) (map[string]Contact, error) {
+	if !slices.Contains(Precedences, precedence) {
+		return nil, fmt.Errorf("unknown contact precedence %s (available: %s)", precedence,
+			strings.Join(Precedences, ", "))
+	}
+
+	merged := make(map[string]Contact, len(feed))
+	for team, contact := range feed {
+		merged[team] = contact
+	}
+	for team, extra := range file {
+		contact, ok := merged[team]
+		if !ok {
+			merged[team] = extra
+			continue
+		}
+		if extra.CoachEmail != "" && (precedence == "file" || contact.CoachEmail == "") {
+			contact.Coach, contact.CoachEmail = extra.Coach, extra.CoachEmail
+		}
+		if extra.ManagerEmail != "" && (precedence == "file" || contact.ManagerEmail == "") {
+			contact.Manager, contact.ManagerEmail = extra.Manager, extra.ManagerEmail
+		}
+		for _, email := range extra.Others {
+			if !slices.Contains(contact.Others, email) {
+				contact.Others = append(contact.Others, email)
+			}
+		}
+		merged[team] = contact
+	}
+	return merged, nil
+}
+
+/*
+Read the contact overrides file, a JSON object of contacts by team name using
+the same fields as the contacts feed. No overrides are returned if the file
+doesn't exist.
+*/
+func ReadOverrides(path string) (map[string]Contact, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var overrides map[string]Contact
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return overrides, nil
+}
+
+/*
+Patch the contacts with the overrides. Only the fields set in an override
+replace the team's contact, so a corrected email doesn't blank the name.
+*/
+func Override(contacts, overrides map[string]Contact) {
+	for team, override := range overrides {
+		contact := contacts[team]
+		contact.Team = team
+		if override.Coach != "" {
+			contact.Coach = override.Coach
+		}
+		if override.CoachEmail != "" {
+			contact.CoachEmail = override.CoachEmail
+		}
+		if override.Manager != "" {
+			contact.Manager = override.Manager
+		}
+		if override.ManagerEmail != "" {
+			contact.ManagerEmail = override.ManagerEmail
+		}
+		if len(override.Others) > 0 {
+			contact.Others = override.Others
+		}
+		contacts[team] = contact
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+/*
+ Credentials
+
+ Passphrases, passwords and tokens are looked up by name, first in a
+ GO_SCHEDULER_<NAME> environment variable, then in the system keychain and
+ last in a credentials file only the user can read. The keychain is used
+ through the security tool on macOS and secret-tool on Linux, other systems
+ use the file. They are stored with the credentials sub-command, i.e.
+
+   go-scheduler credentials set passphrase
+*/
+
+// Service name the credentials are stored under in the keychain
+const keychainService = "go-scheduler"
+
+// File the credentials are stored in when there is no keychain
+var credentialsFile = "credentials.json"
+
+/*
+Return the environment variable for a credential.
+*/
+func credentialEnv(name string) string {
+	return "GO_SCHEDULER_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+/*
+Return the command to read a credential from the keychain, or nil if there
+is no keychain tool for the system.
+*/
+func keychainLookup(name string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("security", "find-generic-password", "-s", keychainService, "-a", name, "-w")
+	case "linux":
+		return exec.Command("secret-tool", "lookup", "service", keychainService, "account", name)
+	}
+	return nil
+}
+
+/*
+Return the command to store a credential in the keychain, or nil if there is
+no keychain tool for the system. The value is written to the tool's stdin
+so it never shows in the process list: secret-tool reads it once and security
+prompts for it twice when -w is given last without a value.
+*/
+func keychainStore(name, value string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		cmd := exec.Command("security", "add-generic-password", "-U", "-s", keychainService, "-a", name, "-w")
+		cmd.Stdin = strings.NewReader(value + "\n" + value + "\n")
+		return cmd
+	case "linux":
+		cmd := exec.Command("secret-tool", "store", "--label="+keychainService+" "+name,
+			"service", keychainService, "account", name)
+		cmd.Stdin = strings.NewReader(value)
+		return cmd
+	}
+	return nil
+}
+
+/*
+Read the credentials file. No credentials are returned if it doesn't exist.
+*/
+func readCredentials() (map[string]string, error) {
+	credentials := make(map[string]string)
+	data, err := os.ReadFile(credentialsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return credentials, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("%s: %w", credentialsFile, err)
+	}
+	return credentials, nil
+}
+
+/*
+Look up a credential. An empty string is returned if it isn't set anywhere.
+*/
+func credential(name string) (string, error) {
+	if value := os.Getenv(credentialEnv(name)); value != "" {
+		return value, nil
+	}
+	if cmd := keychainLookup(name); cmd != nil {
+		// A missing tool or entry falls back to the file
+		if out, err := cmd.Output(); err == nil {
+			return strings.TrimRight(string(out), "\r\n"), nil
+		}
+	}
+	credentials, err := readCredentials()
+	if err != nil {
+		return "", err
+	}
+	return credentials[name], nil
+}
+
+/*
+Store a credential in the keychain, or in the credentials file if the
+keychain isn't available.
+*/
+func setCredential(name, value string) error {
+	if cmd := keychainStore(name, value); cmd != nil {
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+	credentials, err := readCredentials()
+	if err != nil {
+		return err
+	}
+	credentials[name] = value
+	data, err := json.MarshalIndent(credentials, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(credentialsFile, data, 0600)
+}
+
+/*
+Store a credential read from stdin.
+
+	credentials set NAME
+*/
+func credentialsCommand(args []string) error {
+	flags := flag.NewFlagSet("credentials", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler credentials set NAME")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 || flags.Arg(0) != "set" {
+		flags.Usage()
+		return fmt.Errorf("credentials needs set and the name of the credential")
+	}
+
+	fmt.Printf("Enter %s: ", flags.Arg(1))
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && value == "" {
+		return err
+	}
+	return setCredential(flags.Arg(1), strings.TrimRight(value, "\r\n"))
+}
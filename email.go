@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Swap request emails
+
+ Instead of copying addresses out of the report, a draft email asking for the
+ swap can be written for each potential match. The draft is addressed to the
+ contacts of the teams in the match, copied to the contacts of the swap
+ teams, and has the details of both games filled into a template. Drafts are
+ .eml files, which open ready to send in most email programs.
+
+ The template is a Go text template. Its first line is the subject if it
+ starts with "Subject:", the rest is the body. Game times are given in the
+ association's time zone.
+*/
+
+// Template used when no template file is given
+const defaultEmailTemplate = `Subject: Game swap request: {{.Swap.ID}} on {{.Swap.When}}
+Hello {{.Match.Home}} and {{.Match.Away}},
+
+{{.Swap.Home}} and {{.Swap.Away}} need to move our {{.Division}} game {{.Swap.ID}} on {{.Swap.When}} at {{.Swap.Arena}}.
+
+Would you be willing to swap ice times with your game {{.Match.ID}} on {{.Match.When}} at {{.Match.Arena}}? We would play in your ice time and you would play in ours.
+
+Please reply to all to let us know.
+
+Thank you
+`
+
+// Structure to hold the details of a game for the email template
+type emailGame_t struct {
+	ID    string
+	When  string // i.e. Sat Jan 12 at 10:00 AM EST
+	Arena string
+	Home  string
+	Away  string
+}
+
+// Structure to hold the values for the email template
+type emailData_t struct {
+	Division string
+	Swap     emailGame_t
+	Match    emailGame_t
+}
+
+// Structure to hold a swap request email
+type message_t struct {
+	To      []string
+	Cc      []string
+	Subject string
+	Body    string
+}
+
+/*
+Return the details of a game for the email template.
+*/
+func emailGame(game []string) emailGame_t {
+	when := game[schedule.DATE] + " " + game[schedule.TIME]
+	if start, err := schedule.Start(game); err == nil {
+		when = start.Format("Mon Jan 2 at 3:04 PM MST")
+	}
+	return emailGame_t{ID: game[schedule.GAMEID], When: when, Arena: game[schedule.VENUE],
+		Home: game[schedule.HOMETEAM], Away: game[schedule.AWAYTEAM]}
+}
+
+/*
+Read the email template from a file, or use the default template if the file
+name is empty.
+*/
+func readEmailTemplate(fileName string) (*template.Template, error) {
+	text := defaultEmailTemplate
+	if fileName != "" {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	return template.New("email").Parse(text)
+}
+
+/*
+Fill in the swap request email for a potential match.
+*/
+func swapRequest(tmpl *template.Template, report report_t, match []string) (message_t, error) {
+	s := report.swap
+	data := emailData_t{Division: report.division.Name, Swap: emailGame(s.Game), Match: emailGame(match)}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return message_t{}, err
+	}
+	message := message_t{
+		To:   contacts.Addresses(report.contacts, match[schedule.HOMETEAM], match[schedule.AWAYTEAM]),
+		Cc:   contacts.Addresses(report.contacts, s.Home, s.Away),
+		Body: body.String(),
+	}
+	if subject, rest, ok := strings.Cut(message.Body, "\n"); ok && strings.HasPrefix(subject, "Subject:") {
+		message.Subject = strings.TrimSpace(strings.TrimPrefix(subject, "Subject:"))
+		message.Body = rest
+	}
+	return message, nil
+}
+
+/*
+Return the message in the internet message format of an .eml file. The From
+header is left out if from is empty, for the email program to fill in. Drafts
+are marked unsent so they open ready to send.
+*/
+func (message message_t) eml(from string, draft bool) []byte {
+	var b bytes.Buffer
+	if from != "" {
+		fmt.Fprintf(&b, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(message.To, ", "))
+	if len(message.Cc) > 0 {
+		fmt.Fprintf(&b, "Cc: %s\r\n", strings.Join(message.Cc, ", "))
+	}
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().In(schedule.Location()).Format(time.RFC1123Z))
+	if draft {
+		b.WriteString("X-Unsent: 1\r\n")
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return b.Bytes()
+}
+
+/*
+Write a draft swap request email for each potential match to
+<gameId>-emails/<matchId>.eml. Matches with no valid email for either team
+are skipped.
+*/
+func writeEmailDrafts(report report_t, templateFile, from string) error {
+	// create a debugger object
+	var debug = debuggo.Debug("writeEmailDrafts")
+
+	tmpl, err := readEmailTemplate(templateFile)
+	if err != nil {
+		return err
+	}
+
+	dir := report.swap.GameID + "-emails"
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	drafts := 0
+	for _, match := range report.swap.Games {
+		message, err := swapRequest(tmpl, report, match)
+		if err != nil {
+			return err
+		}
+		if len(message.To) == 0 {
+			fmt.Printf("No email for %s or %s, no draft for %s\n", match[schedule.HOMETEAM], match[schedule.AWAYTEAM],
+				match[schedule.GAMEID])
+			continue
+		}
+		fileName := filepath.Join(dir, match[schedule.GAMEID]+".eml")
+		debug("Creating email draft: %s", fileName)
+		if err := os.WriteFile(fileName, message.eml(from, true), 0600); err != nil {
+			return err
+		}
+		drafts++
+	}
+
+	fmt.Printf("Recorded %d swap request emails to %s\n", drafts, dir)
+	return nil
+}
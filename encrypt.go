@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+/*
+ Encrypting saved contacts
+
+ The saved contacts have coaches' and managers' personal emails, so when a
+ passphrase credential is set (i.e. in the GO_SCHEDULER_PASSPHRASE
+ environment variable or the keychain) they are encrypted with AES-GCM using
+ a key derived from the passphrase. The contacts sub-command prints the saved
+ contacts, decrypting them if needed.
+*/
+
+// Name of the credential holding the passphrase for saved contacts
+const passphraseCredential = "passphrase"
+
+// Marks the start of an encrypted file, followed by the salt, nonce and
+// encrypted data
+var encryptedMagic = []byte("go-scheduler encrypted v1\n")
+
+const (
+	saltSize         = 16
+	keyIterations    = 600000
+	encryptedKeySize = 32
+)
+
+/*
+Derive the AES-GCM cipher for a passphrase and salt.
+*/
+func passphraseCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, keyIterations, encryptedKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+/*
+Encrypt data with the passphrase.
+*/
+func encrypt(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	rand.Read(salt)
+	aead, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+
+	out := append(bytes.Clone(encryptedMagic), salt...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, data, nil), nil
+}
+
+/*
+Decrypt data encrypted with the passphrase. Data that isn't encrypted is
+returned as is.
+*/
+func decrypt(data []byte, passphrase string) ([]byte, error) {
+	data, ok := bytes.CutPrefix(data, encryptedMagic)
+	if !ok {
+		return data, nil
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("data is encrypted, set the %s credential", passphraseCredential)
+	}
+	if len(data) < saltSize {
+		return nil, errors.New("encrypted data is truncated")
+	}
+	aead, err := passphraseCipher(passphrase, data[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	data = data[saltSize:]
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("encrypted data is truncated")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("wrong passphrase or corrupted data")
+	}
+	return plain, nil
+}
+
+/*
+Write personal data to a file only the user can read, encrypted when a
+passphrase is set.
+*/
+func writePrivate(path string, data []byte) error {
+	passphrase, err := credential(passphraseCredential)
+	if err != nil {
+		return err
+	}
+	if passphrase != "" {
+		if data, err = encrypt(data, passphrase); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+/*
+Read a file written by writePrivate.
+*/
+func readPrivate(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := credential(passphraseCredential)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(data, passphrase)
+}
+
+/*
+Print the saved contacts.
+
+	contacts [-file file]
+*/
+func contactsCommand(args []string) error {
+	flags := flag.NewFlagSet("contacts", flag.ExitOnError)
+	contactsFile := flags.String("file", "contacts.json", "File the team contacts were saved to")
+	flags.Parse(args)
+
+	data, err := readPrivate(*contactsFile)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
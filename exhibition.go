@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Exhibition suggestions
+
+ When no game can be swapped, the ice on the game date doesn't have to go to
+ waste. Teams in the compatible divisions that aren't playing that day could
+ play an exhibition game against the team instead, even if they have no game
+ to swap.
+*/
+
+/*
+Return the division and team of the teams in the swappable divisions that
+aren't playing on the swap date, sorted by division and team.
+*/
+func findExhibitions(games [][]string, index schedule.Index, s swap.Swap, swappableRe *regexp.Regexp) [][]string {
+	// Teams playing on the date, including the swap teams
+	busy := make(map[string]bool)
+	for _, game := range index[s.Date] {
+		busy[swap.NormalizeTeam(game[schedule.HOMETEAM])] = true
+		busy[swap.NormalizeTeam(game[schedule.AWAYTEAM])] = true
+	}
+
+	seen := make(map[string]bool)
+	var teams [][]string
+	for _, game := range games {
+		if !swappableRe.MatchString(game[schedule.DIVISION]) {
+			continue
+		}
+		for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+			if busy[swap.NormalizeTeam(team)] || seen[swap.NormalizeTeam(team)] {
+				continue
+			}
+			seen[swap.NormalizeTeam(team)] = true
+			teams = append(teams, []string{game[schedule.DIVISION], team})
+		}
+	}
+	slices.SortFunc(teams, func(a, b []string) int {
+		return slices.Compare(a, b)
+	})
+	return teams
+}
+
+/*
+Write the teams free for an exhibition game on the swap date to
+<gameId>-exhibition.csv. The contacts are labelled instead of listing
+their emails when they are redacted.
+*/
+func writeExhibitions(games [][]string, index schedule.Index, s swap.Swap,
+	swappableRe *regexp.Regexp, contactList map[string]contacts.Contact, redact bool) error {
+	// create a debugger object
+	var debug = debuggo.Debug("writeExhibitions")
+
+	teams := findExhibitions(games, index, s, swappableRe)
+
+	fileName := s.GameID + "-exhibition.csv"
+	debug("Creating exhibition file: %s", fileName)
+	csvFile, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	writer.Write([]string{"Division", "Team", "Contacts"})
+	for _, team := range teams {
+		writer.Write([]string{team[0], team[1], contactsFor(redact)(contactList, s.Home, s.Away, team[1])})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	fmt.Printf("No swaps found, recorded %d teams free for an exhibition game on %s to %s\n",
+		len(teams), s.Date, fileName)
+	return nil
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo"
+)
+
+/*
+ Polite fetching
+
+ The league API is shared by every association, so requests to the same host
+ are spaced out by at least fetchInterval plus a random jitter, even when the
+ schedule and contacts are fetched together.
+
+ Responses are cached with their ETag and Last-Modified validators, and the
+ next request for the same URL is conditional, so an unchanged schedule or
+ contacts list isn't sent again.
+
+ A download that drops part way through is resumed with a range request from
+ where it stopped, when the server supports ranges, instead of starting over.
+
+ Requests that fail with a network error or a server error (5xx or 429 Too
+ Many Requests) are retried after a wait that doubles each time, and each
+ request times out so a stalled connection doesn't hang the search.
+*/
+
+var (
+	// Minimum time between requests to the same host
+	fetchInterval = 2 * time.Second
+
+	// Most extra time added at random between requests to the same host
+	fetchJitter = time.Second
+
+	// Time the next request to each host may be sent
+	nextFetch   = make(map[string]time.Time)
+	nextFetchMu sync.Mutex
+)
+
+/*
+Wait until a request to the host is allowed, and reserve the next slot.
+*/
+func throttle(host string) {
+	// create a debugger object
+	var debug = debuggo.Debug("throttle")
+
+	nextFetchMu.Lock()
+	now := time.Now()
+	at := now
+	if next := nextFetch[host]; next.After(now) {
+		at = next
+	}
+	jitter := time.Duration(0)
+	if fetchJitter > 0 {
+		jitter = rand.N(fetchJitter)
+	}
+	nextFetch[host] = at.Add(fetchInterval + jitter)
+	nextFetchMu.Unlock()
+
+	if wait := at.Sub(now); wait > 0 {
+		debug("Waiting %s before fetching from %s", wait, host)
+		// an interrupt cancels the request that follows
+		sleep(wait)
+	}
+}
+
+var (
+	// Number of times a failed request is retried
+	fetchRetries = 3
+
+	// Wait before the first retry, doubled for each retry after
+	fetchBackoff = time.Second
+
+	// Client for all requests, the timeout covers reading the body
+	fetchClient = &http.Client{Timeout: 2 * time.Minute}
+)
+
+/*
+Check if a request should be retried after a response status.
+*/
+func retryable(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+/*
+Send a request, retrying network and server errors with exponential backoff.
+The last response is returned if the retries run out, so the caller can
+report its status.
+*/
+func doWithRetry(req *http.Request) (*http.Response, error) {
+	// create a debugger object
+	var debug = debuggo.Debug("doWithRetry")
+
+	wait := fetchBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := fetchClient.Do(req)
+		if err == nil && !retryable(resp.StatusCode) {
+			return resp, nil
+		}
+		if attempt >= fetchRetries {
+			return resp, err
+		}
+
+		if err != nil {
+			debug("Request for %s failed, retrying in %s: %v", req.URL, wait, err)
+		} else {
+			debug("Request for %s got %s, retrying in %s", req.URL, resp.Status, wait)
+			resp.Body.Close()
+		}
+		if err := sleep(wait); err != nil {
+			return nil, err
+		}
+		wait *= 2
+		throttle(req.URL.Host)
+	}
+}
+
+// Number of times a dropped download is resumed
+var fetchResumes = 3
+
+/*
+Read the body of a response, resuming it with range requests if the
+connection drops. The response must have a validator so the resumed parts
+are known to come from the same version.
+*/
+func readResumable(req *http.Request, resp *http.Response) ([]byte, error) {
+	// create a debugger object
+	var debug = debuggo.Debug("readResumable")
+
+	validator := resp.Header.Get("ETag")
+	if validator == "" {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	canResume := resp.Header.Get("Accept-Ranges") == "bytes" && validator != ""
+
+	var body bytes.Buffer
+	for resumes := 0; ; resumes++ {
+		_, err := io.Copy(&body, resp.Body)
+		resp.Body.Close()
+		if err == nil {
+			return body.Bytes(), nil
+		}
+		if !canResume || resumes >= fetchResumes {
+			return nil, err
+		}
+
+		debug("Download dropped after %d bytes, resuming: %v", body.Len(), err)
+		throttle(req.URL.Host)
+		ranged := req.Clone(req.Context())
+		ranged.Header.Del("If-None-Match")
+		ranged.Header.Del("If-Modified-Since")
+		ranged.Header.Set("Range", fmt.Sprintf("bytes=%d-", body.Len()))
+		ranged.Header.Set("If-Range", validator)
+		resp, err = doWithRetry(ranged)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusPartialContent &&
+			strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", body.Len())):
+		case resp.StatusCode == http.StatusOK:
+			// The response changed, or the range was ignored, so start over
+			body.Reset()
+		default:
+			resp.Body.Close()
+			return nil, fmt.Errorf("resuming %s: %s", req.URL, resp.Status)
+		}
+	}
+}
+
+// Directory the responses are cached in for conditional requests
+var cacheDir = "cache"
+
+// Structure to hold the validators of a cached response
+type cacheMeta_t struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
+/*
+Return the path of the cache files for a URL, without an extension.
+*/
+func cachePath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:8]))
+}
+
+/*
+Read the cached response for a URL. ok is false if there isn't one.
+*/
+func readCache(rawURL string) (meta cacheMeta_t, body []byte, ok bool) {
+	data, err := os.ReadFile(cachePath(rawURL) + ".json")
+	if err != nil || json.Unmarshal(data, &meta) != nil || meta.URL != rawURL {
+		return meta, nil, false
+	}
+	body, err = readPrivate(cachePath(rawURL) + ".body")
+	if err != nil {
+		return meta, nil, false
+	}
+	return meta, body, true
+}
+
+/*
+Cache the response for a URL. Responses can have contact details, so the body
+is written like other personal data.
+*/
+func writeCache(meta cacheMeta_t, body []byte) error {
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return err
+	}
+	if err := writePrivate(cachePath(meta.URL)+".body", body); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cachePath(meta.URL)+".json", data, 0600)
+}
+
+/*
+GET a URL and return the body, sending the validators from the cached
+response so an unchanged response (304) is served from the cache.
+*/
+func fetchCached(rawURL string) ([]byte, error) {
+	// create a debugger object
+	var debug = debuggo.Debug("fetchCached")
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	throttle(u.Host)
+
+	req, err := http.NewRequestWithContext(runContext, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "go-scheduler")
+	meta, cached, ok := readCache(rawURL)
+	if ok {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := doWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		debug("%s not modified since %s", rawURL, meta.Fetched.Format(time.RFC3339))
+		return cached, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("fetching %s: %s", rawURL, resp.Status)
+	}
+
+	body, err := readResumable(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	meta = cacheMeta_t{
+		URL:          rawURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}
+	// Without validators the next request can't be conditional, but the
+	// response can still be reused while it's fresh
+	if err := writeCache(meta, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Free-date matrix
+
+ When the first proposal is declined it helps to know which other weekends
+ each candidate team could play. The matrix has a row for each team (the
+ teams in the swap first, then the candidate teams) and a column for each
+ weekend after the cut off date. Each cell is either "free" or the ids of the
+ games the team is already playing that weekend.
+*/
+
+/*
+Return the free-date matrix for the teams in the swap and its potential
+matches, header row first. Weekends are grouped the same way as the summary.
+*/
+func freeMatrix(s swap.Swap, cutOffDate time.Time) [][]string {
+	// Teams in the swap followed by the teams in the potential matches
+	var teams []string
+	for _, team := range []string{s.Home, s.Away} {
+		teams = swap.AddUnique(teams, team)
+	}
+	for _, game := range s.Games {
+		teams = swap.AddUnique(teams, game[schedule.HOMETEAM])
+		teams = swap.AddUnique(teams, game[schedule.AWAYTEAM])
+	}
+
+	// Find the games each team plays each weekend using the full schedule
+	played := make(map[string]map[string][]string)
+	weekends := make(map[string]bool)
+	for date, games := range s.Index {
+		gameDate, err := time.Parse(schedule.DATE_FORMAT, date)
+		if err != nil || gameDate.Before(cutOffDate) {
+			continue
+		}
+		weekend := weekendOf(date)
+		weekends[weekend] = true
+		for _, game := range games {
+			for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+				team = swap.NormalizeTeam(team)
+				if played[team] == nil {
+					played[team] = make(map[string][]string)
+				}
+				played[team][weekend] = append(played[team][weekend], game[schedule.GAMEID])
+			}
+		}
+	}
+	columns := slices.Sorted(maps.Keys(weekends))
+
+	matrix := [][]string{append([]string{"Team"}, columns...)}
+	for _, team := range teams {
+		row := []string{team}
+		for _, weekend := range columns {
+			ids := played[team][weekend]
+			if len(ids) == 0 {
+				row = append(row, "free")
+				continue
+			}
+			slices.Sort(ids)
+			row = append(row, strings.Join(ids, " "))
+		}
+		matrix = append(matrix, row)
+	}
+	return matrix
+}
+
+/*
+Write the free-date matrix for the swap to <gameId>-free.csv.
+*/
+func writeFreeMatrix(s swap.Swap, cutOffDate time.Time) error {
+	// create a debugger object
+	var debug = debuggo.Debug("writeFreeMatrix")
+
+	matrix := freeMatrix(s, cutOffDate)
+
+	fileName := s.GameID + "-free.csv"
+	debug("Creating free-date matrix file: %s", fileName)
+	csvFile, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	writer.WriteAll(matrix)
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recorded free dates for %d teams to %s\n", len(matrix)-1, fileName)
+	return nil
+}
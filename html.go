@@ -0,0 +1,220 @@
+package main
+
+import (
+	"hash/fnv"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ HTML report
+
+ The report as a single web page to send to another team's manager, who can
+ open it in any browser without a spreadsheet program. The potential matches
+ are in a table that sorts by clicking a column heading and filters as text
+ is typed. Each division has its own colour and weekend games are shaded, so
+ the options stand out at a glance. Strong matches are in bold and long shots
+ are greyed out, so it's clear where to start.
+
+ Above the table a calendar of the months with potential matches shades each
+ day by the number of matches on it, so it's clear which weekends even have
+ options. Everything is in the one file, with no scripts or styles loaded
+ from elsewhere.
+*/
+
+// Number of shades in the calendar, from no matches to the busiest day
+const HEAT_LEVELS = 4
+
+// HTML output
+type htmlOutput struct{}
+
+func (htmlOutput) Ext() string { return ".html" }
+
+// Structure to hold a row of the table
+type htmlRow_t struct {
+	Cells   []string
+	Hue     int    // colour of the division, 0 to 359
+	Weekend bool   // game is on a Saturday or Sunday
+	Tier    string // strong, possible or long-shot
+}
+
+// Structure to hold a day of the calendar, Day is 0 for the blanks before
+// the first of the month
+type heatDay_t struct {
+	Day     int
+	Date    string
+	Matches int
+	Level   int // shade, 0 to HEAT_LEVELS
+}
+
+// Structure to hold a month of the calendar, a week per row
+type heatMonth_t struct {
+	Name  string // i.e. January 2025
+	Weeks [][]heatDay_t
+}
+
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Potential swaps for {{.Game}}</title>
+<style>
+body { font-family: sans-serif; margin: 1.5em; }
+h1 { font-size: 1.5em; margin: 0 0 0.2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.5em; text-align: left; vertical-align: top; }
+#matches th { background: #eee; cursor: pointer; user-select: none; }
+#matches th.asc::after { content: " \25B2"; }
+#matches th.desc::after { content: " \25BC"; }
+#matches td:first-child { border-left: 0.5em solid hsl(var(--hue), 60%, 50%); }
+#matches tr.weekend td { background: #fff8e0; }
+#matches tr.strong td { font-weight: bold; }
+#matches tr.long-shot td { color: #888; }
+#filter { margin: 0.8em 0; padding: 0.3em; width: 20em; }
+.months { display: flex; flex-wrap: wrap; gap: 1.5em; margin: 1em 0; }
+.month caption { font-weight: bold; padding-bottom: 0.3em; }
+.month th, .month td { border: none; width: 1.8em; height: 1.8em; text-align: center; padding: 0; font-size: 0.8em; }
+.month td.level0 { color: #999; }
+.month td.level1 { background: #c6e48b; }
+.month td.level2 { background: #7bc96f; }
+.month td.level3 { background: #239a3b; color: white; }
+.month td.level4 { background: #196127; color: white; }
+.summary td:last-child { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Potential swaps for {{.Game}}</h1>
+<p>{{.Date}} {{.Time}} at {{.Arena}}: {{.Home}} vs {{.Away}} ({{.Division}}). {{len .Rows}} potential matches.</p>
+{{if .Months}}<div class="months">
+{{range .Months}}<table class="month">
+<caption>{{.Name}}</caption>
+<tr><th>S</th><th>M</th><th>T</th><th>W</th><th>T</th><th>F</th><th>S</th></tr>
+{{range .Weeks}}<tr>{{range .}}{{if .Day}}<td class="level{{.Level}}" title="{{.Date}}: {{.Matches}} matches">{{.Day}}</td>{{else}}<td></td>{{end}}{{end}}</tr>
+{{end}}</table>
+{{end}}</div>
+{{end}}<input id="filter" type="search" placeholder="Filter matches">
+<table id="matches">
+<thead><tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr class="{{if .Weekend}}weekend {{end}}{{.Tier}}" style="--hue: {{.Hue}}">{{range .Cells}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{if .Summary}}<h2>Summary</h2>
+<table class="summary">
+{{range .Summary}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}<script>
+const table = document.getElementById("matches");
+const body = table.tBodies[0];
+document.getElementById("filter").addEventListener("input", e => {
+  const text = e.target.value.toLowerCase();
+  for (const row of body.rows) {
+    row.hidden = !row.textContent.toLowerCase().includes(text);
+  }
+});
+table.querySelectorAll("th").forEach((th, col) => th.addEventListener("click", () => {
+  const dir = th.classList.contains("asc") ? -1 : 1;
+  table.querySelectorAll("th").forEach(h => h.classList.remove("asc", "desc"));
+  th.classList.add(dir > 0 ? "asc" : "desc");
+  const rows = Array.from(body.rows);
+  rows.sort((a, b) => dir * a.cells[col].textContent.localeCompare(
+    b.cells[col].textContent, undefined, {numeric: true}));
+  rows.forEach(row => body.appendChild(row));
+}));
+</script>
+</body>
+</html>
+`))
+
+/*
+Return the colour of a division, the same every time for the same name.
+*/
+func divisionHue(division string) int {
+	h := fnv.New32a()
+	h.Write([]byte(division))
+	return int(h.Sum32() % 360)
+}
+
+/*
+Return the calendar of the months from the first to the last potential
+match, with each day shaded by the number of matches on it.
+*/
+func heatMap(games [][]string) []heatMonth_t {
+	counts := make(map[string]int)
+	var first, last time.Time
+	busiest := 0
+	for _, g := range games {
+		d, err := time.Parse(schedule.DATE_FORMAT, g[schedule.DATE])
+		if err != nil {
+			continue
+		}
+		counts[g[schedule.DATE]]++
+		busiest = max(busiest, counts[g[schedule.DATE]])
+		if first.IsZero() || d.Before(first) {
+			first = d
+		}
+		if d.After(last) {
+			last = d
+		}
+	}
+	if busiest == 0 {
+		return nil
+	}
+
+	var months []heatMonth_t
+	for month := first.AddDate(0, 0, 1-first.Day()); !month.After(last); month = month.AddDate(0, 1, 0) {
+		heat := heatMonth_t{Name: month.Format("January 2006")}
+		week := make([]heatDay_t, int(month.Weekday()))
+		for d := month; d.Month() == month.Month(); d = d.AddDate(0, 0, 1) {
+			date := d.Format(schedule.DATE_FORMAT)
+			// round up so a day with any match is shaded
+			level := (counts[date]*HEAT_LEVELS + busiest - 1) / busiest
+			week = append(week, heatDay_t{Day: d.Day(), Date: date, Matches: counts[date], Level: level})
+			if d.Weekday() == time.Saturday {
+				heat.Weeks = append(heat.Weeks, week)
+				week = nil
+			}
+		}
+		if len(week) > 0 {
+			heat.Weeks = append(heat.Weeks, week)
+		}
+		months = append(months, heat)
+	}
+	return months
+}
+
+func (htmlOutput) Write(w io.Writer, report report_t) error {
+	s := report.swap
+	header, cells := report.table()
+	rows := make([]htmlRow_t, len(cells))
+	for i, g := range s.Games {
+		weekend := false
+		if d, err := time.Parse(schedule.DATE_FORMAT, g[schedule.DATE]); err == nil {
+			weekend = d.Weekday() == time.Saturday || d.Weekday() == time.Sunday
+		}
+		rows[i] = htmlRow_t{Cells: cells[i], Hue: divisionHue(g[schedule.DIVISION]), Weekend: weekend,
+			Tier: report.tiers[g[schedule.GAMEID]]}
+	}
+
+	var summary [][]string
+	if report.summary {
+		summary = report.summaryRows()[1:]
+	}
+	return htmlTemplate.Execute(w, map[string]any{
+		"Game":     s.GameID,
+		"Date":     s.Date,
+		"Time":     s.Game[schedule.TIME],
+		"Arena":    s.Game[schedule.VENUE],
+		"Home":     s.Home,
+		"Away":     s.Away,
+		"Division": report.division.Name,
+		"Months":   heatMap(s.Games),
+		"Header":   header,
+		"Rows":     rows,
+		"Summary":  summary,
+	})
+}
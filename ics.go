@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Calendar output
+
+ The potential matches as an iCalendar file, to import into Google Calendar
+ or Outlook next to the team's own calendar and see which slots work. Each
+ match is an event at its ice time with the teams, division and contacts.
+ Times are given in UTC so every calendar places them correctly, with the
+ association's time zone named for display. Games without a time, or with
+ one that isn't understood like TBA, are all day events, and games with a
+ date that isn't understood are left out with a warning.
+*/
+
+// Length of the calendar event for a game
+const GAME_LENGTH = 75 * time.Minute
+
+// Longest line allowed in the file, in bytes
+const ICS_LINE = 75
+
+// Calendar output
+type icsOutput struct{}
+
+func (icsOutput) Ext() string { return ".ics" }
+
+/*
+Escape text for an iCalendar property value.
+*/
+func icsEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+/*
+Write a content line, folded so no line is longer than ICS_LINE bytes.
+Lines are only folded between characters so UTF-8 stays intact.
+*/
+func icsLine(b *bytes.Buffer, line string) {
+	limit := ICS_LINE
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// the space starting a continuation line counts against the limit
+		limit = ICS_LINE - 1
+	}
+	b.WriteString(line + "\r\n")
+}
+
+/*
+Return true if the byte starts a UTF-8 character.
+*/
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+func (icsOutput) Write(w io.Writer, report report_t) error {
+	s := report.swap
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	emails := contactsFor(report.redact)
+
+	var b bytes.Buffer
+	icsLine(&b, "BEGIN:VCALENDAR")
+	icsLine(&b, "VERSION:2.0")
+	icsLine(&b, "PRODID:-//go-scheduler//Potential swaps//EN")
+	icsLine(&b, "CALSCALE:GREGORIAN")
+	icsLine(&b, "X-WR-CALNAME:"+icsEscape("Swaps for "+s.GameID))
+	icsLine(&b, "X-WR-TIMEZONE:"+schedule.Location().String())
+	for _, g := range s.Games {
+		// A time that isn't understood, like TBA, makes an all day event
+		allDay := strings.TrimSpace(g[schedule.TIME]) == ""
+		start, err := schedule.Start(g)
+		if err != nil {
+			date, dateErr := time.ParseInLocation(schedule.DATE_FORMAT, g[schedule.DATE], schedule.Location())
+			if dateErr != nil {
+				fmt.Printf("Warning: %v, %s left out of the calendar\n", err, g[schedule.GAMEID])
+				continue
+			}
+			start, allDay = date, true
+		}
+
+		description := fmt.Sprintf("Swap with %s on %s %s (%s vs %s)\nDivision: %s\nContacts: %s",
+			s.GameID, s.Date, s.Game[schedule.TIME], s.Home, s.Away, g[schedule.DIVISION],
+			emails(report.contacts, g[schedule.HOMETEAM], g[schedule.AWAYTEAM]))
+		if allDay && strings.TrimSpace(g[schedule.TIME]) != "" {
+			description += "\nTime: " + g[schedule.TIME]
+		}
+		if warnings := report.warnings[g[schedule.GAMEID]]; len(warnings) > 0 {
+			description += "\nWarnings: " + strings.Join(warnings, "; ")
+		}
+
+		icsLine(&b, "BEGIN:VEVENT")
+		icsLine(&b, "UID:"+g[schedule.GAMEID]+"-"+s.GameID+"@go-scheduler")
+		icsLine(&b, "DTSTAMP:"+stamp)
+		if allDay {
+			icsLine(&b, "DTSTART;VALUE=DATE:"+start.Format("20060102"))
+		} else {
+			icsLine(&b, "DTSTART:"+start.UTC().Format("20060102T150405Z"))
+			icsLine(&b, "DTEND:"+start.Add(GAME_LENGTH).UTC().Format("20060102T150405Z"))
+		}
+		icsLine(&b, "SUMMARY:"+icsEscape(fmt.Sprintf("Swap %s? %s vs %s", g[schedule.GAMEID], g[schedule.HOMETEAM],
+			g[schedule.AWAYTEAM])))
+		icsLine(&b, "LOCATION:"+icsEscape(g[schedule.VENUE]))
+		icsLine(&b, "DESCRIPTION:"+icsEscape(description))
+		icsLine(&b, "TRANSP:TRANSPARENT")
+		icsLine(&b, "END:VEVENT")
+	}
+	icsLine(&b, "END:VCALENDAR")
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
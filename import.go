@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Importing earlier reports
+
+ Versions before the tracker only left the <gameId>.csv reports and
+ contacts.json behind. The import sub-command reads the reports into the
+ tracker, so the searches, the potential matches proposed and any responses
+ typed into them carry forward, i.e.
+
+   go-scheduler import
+   go-scheduler import HLU1501.csv HLU1622.csv
+
+ Without file names every report in the current directory is imported.
+ Searches already in the tracker keep their dates and proposed matches, the
+ responses from the reports are added to them. The saved contacts are
+ rewritten in the current format, encrypted when a passphrase is set.
+*/
+
+/*
+Return the ids of the potential matches in a report and its responses, or
+ok false if the file isn't a report of potential matches.
+*/
+func readReport(fileName string) (ids []string, responses map[string]string, ok bool, err error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer f.Close()
+
+	// Reports from some earlier versions end with a summary, which has fewer
+	// columns
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil || len(rows) == 0 {
+		return nil, nil, false, err
+	}
+	header := rows[0]
+	if len(header) <= schedule.AWAYTEAM+1 || !slices.Equal(header[:schedule.AWAYTEAM+1], reportHeader[:schedule.AWAYTEAM+1]) ||
+		!slices.Contains(header, "Contacts") {
+		return nil, nil, false, nil
+	}
+
+	// The matches end at the blank line before the summary
+	for _, row := range rows[1:] {
+		if len(row) <= schedule.AWAYTEAM || row[schedule.GAMEID] == "" {
+			break
+		}
+		ids = append(ids, row[schedule.GAMEID])
+	}
+	responses, err = readResponses(fileName)
+	return ids, responses, true, err
+}
+
+// Structure to hold a report to import
+type legacyReport_t struct {
+	gameId    string
+	ids       []string          // potential matches
+	responses map[string]string // responses by match game id
+	modTime   time.Time         // when the report was written
+}
+
+/*
+Read a report to import, under the game id in its file name. Returns false
+if the file isn't a report.
+*/
+func readLegacyReport(fileName string) (legacyReport_t, bool, error) {
+	gameId := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	// Other CSV files written with a game id have a suffix, i.e. -change
+	if strings.Contains(gameId, "-") {
+		return legacyReport_t{}, false, nil
+	}
+	ids, responses, ok, err := readReport(fileName)
+	if err != nil || !ok {
+		return legacyReport_t{}, false, err
+	}
+	fi, err := os.Stat(fileName)
+	if err != nil {
+		return legacyReport_t{}, false, err
+	}
+	return legacyReport_t{gameId: gameId, ids: ids, responses: responses, modTime: fi.ModTime()}, true, nil
+}
+
+/*
+Add a report to the tracker. A search already tracked keeps its date and
+proposed matches.
+*/
+func (tracker *tracker_t) importReport(report legacyReport_t) {
+	if tracker.addSearch(report.gameId) {
+		tracker.Searches[report.gameId] = report.modTime
+	}
+	if _, proposed := tracker.Proposed[report.gameId]; !proposed {
+		tracker.propose(report.gameId, report.ids)
+	}
+	if len(report.responses) > 0 {
+		tracker.setResponses(report.gameId, report.responses)
+	}
+}
+
+/*
+Rewrite saved contacts in the current format. Nothing is done if the file
+doesn't exist.
+*/
+func importContacts(fileName string) (int, error) {
+	data, err := readPrivate(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var teams []contacts.Contact
+	if err := json.Unmarshal(data, &teams); err != nil {
+		return 0, fmt.Errorf("%s: %w", fileName, err)
+	}
+	data, err = json.MarshalIndent(teams, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	return len(teams), writePrivate(fileName, data)
+}
+
+/*
+Import reports and contacts saved by earlier versions.
+
+	import [-tracker file] [-contacts file] [REPORT.csv...]
+*/
+func importCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	trackerFile := flags.String("tracker", "tracker.json", "File the swap tracker is stored in")
+	contactsFile := flags.String("contacts", "contacts.json", "File the team contacts were saved to")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler import [options] [REPORT.csv...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	files := flags.Args()
+	if len(files) == 0 {
+		var err error
+		if files, err = filepath.Glob("*.csv"); err != nil {
+			return err
+		}
+	}
+
+	var reports []legacyReport_t
+	var imported []string
+	for _, fileName := range files {
+		report, ok, err := readLegacyReport(fileName)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fileName, err)
+		}
+		if ok {
+			reports = append(reports, report)
+			imported = append(imported, fileName)
+		}
+	}
+	_, err := updateTracker(*trackerFile, func(tracker *tracker_t) {
+		for _, report := range reports {
+			tracker.importReport(report)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, fileName := range imported {
+		fmt.Println("  " + fileName)
+	}
+	fmt.Printf("Imported %d reports to %s\n", len(imported), *trackerFile)
+
+	teams, err := importContacts(*contactsFile)
+	if err != nil {
+		return err
+	}
+	if teams > 0 {
+		fmt.Printf("Saved %d team contacts to %s\n", teams, *contactsFile)
+	}
+	return nil
+}
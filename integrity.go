@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Schedule integrity
+
+ A dropped connection can leave a partial response that still decodes up to
+ the break, and searching it would miss games. The hash and record count of
+ each downloaded schedule are recorded, and a download that doesn't decode or
+ has far fewer records than the last one is fetched again. If the same short
+ schedule comes back it is what the league has, and it is used with a warning.
+
+ A good download is used again without asking the API until it is older than
+ scheduleTTL, as the schedule rarely changes between runs in the same hour,
+ and is used with a warning if the API can't be reached.
+*/
+
+// How long a downloaded schedule is used before it is fetched again, 0 to
+// always fetch
+var scheduleTTL = time.Hour
+
+// Number of times a bad schedule download is fetched again
+var scheduleRetries = 2
+
+// Share of the last schedule's records a new download must have
+const minScheduleShare = 0.9
+
+// Structure to hold the integrity record of a downloaded schedule
+type integrity_t struct {
+	Hash    string    `json:"hash"`    // SHA-256 of the response body
+	Records int       `json:"records"` // number of games decoded
+	Fetched time.Time `json:"fetched"`
+}
+
+/*
+Return the path of the integrity record for a URL.
+*/
+func integrityPath(rawURL string) string {
+	return cachePath(rawURL) + ".integrity.json"
+}
+
+/*
+Read the integrity record of the last good download of a URL.
+*/
+func readIntegrity(rawURL string) (integrity_t, error) {
+	var last integrity_t
+	data, err := os.ReadFile(integrityPath(rawURL))
+	if errors.Is(err, fs.ErrNotExist) {
+		return last, nil
+	}
+	if err != nil {
+		return last, err
+	}
+	return last, json.Unmarshal(data, &last)
+}
+
+/*
+Save the integrity record of a good download of a URL.
+*/
+func writeIntegrity(rawURL string, record integrity_t) error {
+	if err := os.MkdirAll(filepath.Dir(integrityPath(rawURL)), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(integrityPath(rawURL), data, 0600)
+}
+
+/*
+Remove the cached response for a URL so the next fetch is unconditional.
+*/
+func dropCache(rawURL string) {
+	os.Remove(cachePath(rawURL) + ".json")
+	os.Remove(cachePath(rawURL) + ".body")
+}
+
+/*
+Decode the games in a schedule response body.
+*/
+func decodeSchedule(body []byte) ([]schedule.Record, error) {
+	var records []schedule.Record
+	err := schedule.Decode(bytes.NewReader(body), func(r schedule.Record) error {
+		records = append(records, r)
+		return nil
+	})
+	return records, err
+}
+
+/*
+Fetch and decode a schedule, fetching it again if it doesn't decode or is
+much shorter than the last download. The cached schedule is used if it was
+downloaded within scheduleTTL.
+*/
+func fetchSchedule(rawURL string) ([]schedule.Record, error) {
+	// create a debugger object
+	var debug = debuggo.Debug("fetchSchedule")
+
+	last, err := readIntegrity(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Since(last.Fetched) < scheduleTTL {
+		if _, body, ok := readCache(rawURL); ok {
+			records, err := decodeSchedule(body)
+			if err == nil && len(records) == last.Records {
+				debug("Using schedule downloaded at %s", last.Fetched.Format(time.Kitchen))
+				return records, nil
+			}
+		}
+	}
+
+	var previousHash string
+	for attempt := 0; ; attempt++ {
+		body, err := fetchCached(rawURL)
+		if err != nil {
+			// The last good download is better than nothing when the API
+			// is down
+			if _, cached, ok := readCache(rawURL); ok {
+				if records, decodeErr := decodeSchedule(cached); decodeErr == nil {
+					fmt.Printf("Warning: %v, using the schedule downloaded %s\n",
+						err, last.Fetched.Format(schedule.DATE_FORMAT))
+					return records, nil
+				}
+			}
+			return nil, err
+		}
+		sum := sha256.Sum256(body)
+		record := integrity_t{Hash: hex.EncodeToString(sum[:]), Fetched: time.Now()}
+
+		records, err := decodeSchedule(body)
+		record.Records = len(records)
+
+		short := float64(record.Records) < minScheduleShare*float64(last.Records)
+		switch {
+		case err == nil && !short:
+			debug("Schedule %s has %d games", record.Hash[:12], record.Records)
+			return records, writeIntegrity(rawURL, record)
+		case err == nil && record.Hash == previousHash:
+			// The same schedule twice isn't a broken download
+			fmt.Printf("Warning: schedule has %d games, down from %d on %s\n",
+				record.Records, last.Records, last.Fetched.Format(schedule.DATE_FORMAT))
+			return records, writeIntegrity(rawURL, record)
+		case attempt >= scheduleRetries:
+			if err != nil {
+				return nil, fmt.Errorf("error decoding the schedule: %w", err)
+			}
+			return nil, fmt.Errorf("schedule has %d games, down from %d, the download may be incomplete",
+				record.Records, last.Records)
+		}
+
+		if err != nil {
+			debug("Schedule didn't decode, fetching again: %v", err)
+		} else {
+			debug("Schedule has %d games, down from %d, fetching again", record.Records, last.Records)
+		}
+		previousHash = record.Hash
+		dropCache(rawURL)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ JSON output
+
+ The report as JSON for other tools (i.e. a web page or a script) to read
+ without parsing the CSV. The swap game, each potential match with its
+ contacts, response, notes and warnings, and optionally the summary are
+ given as objects. Contact emails are left out when the report is redacted.
+*/
+
+// JSON output
+type jsonOutput struct{}
+
+func (jsonOutput) Ext() string { return ".json" }
+
+// Structure to hold a game in the JSON report
+type jsonGame_t struct {
+	ID       string            `json:"id"`
+	Division string            `json:"division"`
+	Date     string            `json:"date"`
+	Time     string            `json:"time"`
+	Venue    string            `json:"venue"`
+	Home     string            `json:"home"`
+	Away     string            `json:"away"`
+	Extra    map[string]string `json:"extra,omitempty"` // extra schedule columns by name
+}
+
+// Structure to hold a team's contacts in the JSON report
+type jsonContact_t struct {
+	Team         string   `json:"team"`
+	Coach        string   `json:"coach,omitempty"`
+	CoachEmail   string   `json:"coachEmail,omitempty"`
+	Manager      string   `json:"manager,omitempty"`
+	ManagerEmail string   `json:"managerEmail,omitempty"`
+	Others       []string `json:"others,omitempty"`
+}
+
+// Structure to hold a potential match in the JSON report
+type jsonMatch_t struct {
+	jsonGame_t
+	Contacts []jsonContact_t `json:"contacts,omitempty"`
+	Response string          `json:"response,omitempty"`
+	Notes    []note_t        `json:"notes,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Score    *float64        `json:"score,omitempty"`
+	Tier     string          `json:"tier,omitempty"`
+}
+
+// Structure to hold the summary in the JSON report
+type jsonSummary_t struct {
+	PotentialMatches int            `json:"potentialMatches"`
+	Funnel           []swap.Step    `json:"funnel"`
+	ByDivision       map[string]int `json:"byDivision"`
+	ByWeekend        map[string]int `json:"byWeekend"`
+	ByArena          map[string]int `json:"byArena"`
+}
+
+// Structure to hold the JSON report
+type jsonReport_t struct {
+	Swap     jsonGame_t     `json:"swap"`
+	Division string         `json:"division"`
+	Swaps    string         `json:"swapsWith"` // divisions searched
+	Matches  []jsonMatch_t  `json:"matches"`
+	Summary  *jsonSummary_t `json:"summary,omitempty"`
+}
+
+/*
+Return a game for the JSON report with its extra columns.
+*/
+func jsonGame(game []string, extra []string) jsonGame_t {
+	g := jsonGame_t{ID: game[schedule.GAMEID], Division: game[schedule.DIVISION], Date: game[schedule.DATE],
+		Time: game[schedule.TIME], Venue: game[schedule.VENUE], Home: game[schedule.HOMETEAM], Away: game[schedule.AWAYTEAM]}
+	for i, name := range extra {
+		if schedule.AWAYTEAM+1+i < len(game) {
+			if g.Extra == nil {
+				g.Extra = make(map[string]string)
+			}
+			g.Extra[name] = game[schedule.AWAYTEAM+1+i]
+		}
+	}
+	return g
+}
+
+/*
+Return the contacts for a team for the JSON report.
+*/
+func jsonContact(contacts map[string]contacts.Contact, team string) jsonContact_t {
+	contact := contacts[team]
+	return jsonContact_t{Team: team, Coach: contact.Coach, CoachEmail: contact.CoachEmail,
+		Manager: contact.Manager, ManagerEmail: contact.ManagerEmail, Others: contact.Others}
+}
+
+func (jsonOutput) Write(w io.Writer, report report_t) error {
+	s := report.swap
+	out := jsonReport_t{
+		Swap:     jsonGame(s.Game, report.extra),
+		Division: report.division.Name,
+		Swaps:    report.division.Swaps,
+		Matches:  []jsonMatch_t{},
+	}
+	for _, g := range s.Games {
+		match := jsonMatch_t{
+			jsonGame_t: jsonGame(g, report.extra),
+			Response:   report.responses[g[schedule.GAMEID]],
+			Notes:      report.notes[g[schedule.GAMEID]],
+			Warnings:   report.warnings[g[schedule.GAMEID]],
+			Tier:       report.tiers[g[schedule.GAMEID]],
+		}
+		if score, ok := report.scores[g[schedule.GAMEID]]; ok {
+			match.Score = &score
+		}
+		if !report.redact {
+			match.Contacts = []jsonContact_t{jsonContact(report.contacts, g[schedule.HOMETEAM]),
+				jsonContact(report.contacts, g[schedule.AWAYTEAM])}
+		}
+		out.Matches = append(out.Matches, match)
+	}
+	if report.summary {
+		out.Summary = &jsonSummary_t{
+			PotentialMatches: len(s.Games),
+			Funnel:           s.Funnel,
+			ByDivision:       countBy(s.Games, func(g []string) string { return g[schedule.DIVISION] }),
+			ByWeekend:        countBy(s.Games, func(g []string) string { return weekendOf(g[schedule.DATE]) }),
+			ByArena:          countBy(s.Games, func(g []string) string { return g[schedule.VENUE] }),
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(out)
+}
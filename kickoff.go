@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Season kickoff mailing list
+
+ At the start of the season managers can introduce themselves to the teams
+ they are likely to swap with, before the first swap is needed in a hurry.
+ The kickoff command lists the contacts of every team in the divisions a team
+ can swap with, and prints their emails ready to paste into an email.
+*/
+
+/*
+Return the teams in the schedule in divisions matching the swappable
+divisions expression, sorted by division and team, leaving out the team
+itself.
+*/
+func swappableTeams(games [][]string, team, swapsRegex string) ([][2]string, error) {
+	re, err := swap.CompileCached(swapsRegex)
+	if err != nil {
+		return nil, err
+	}
+	var teams [][2]string
+	for _, game := range games {
+		if !re.MatchString(game[schedule.DIVISION]) {
+			continue
+		}
+		for _, name := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+			entry := [2]string{game[schedule.DIVISION], name}
+			if swap.NormalizeTeam(name) != team && !slices.Contains(teams, entry) {
+				teams = append(teams, entry)
+			}
+		}
+	}
+	slices.SortFunc(teams, func(a, b [2]string) int {
+		if c := strings.Compare(a[0], b[0]); c != 0 {
+			return c
+		}
+		return strings.Compare(a[1], b[1])
+	})
+	return teams, nil
+}
+
+/*
+Write the contacts of the teams a team can swap with to kickoff-<team>.csv
+and print their emails.
+
+	kickoff [-source plugin] [-plugins dir] [-contacts-file file] [-preset name] TEAM
+*/
+func kickoffCommand(args []string) error {
+	flags := flag.NewFlagSet("kickoff", flag.ExitOnError)
+	source := flags.String("source", "",
+		"Name of the plugin to get the schedule and contacts from instead of TTM")
+	pluginDir := flags.String("plugins", "plugins", "Directory containing data source plugins")
+	contactsFile := flags.String("contacts-file", "",
+		"CSV file of supplemental contacts (Team, Role, Name, Email) to merge with the feed")
+	orgFlags(flags)
+	presetFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kickoff [-source plugin] [-plugins dir] [-contacts-file file] [-preset name] TEAM")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return fmt.Errorf("kickoff needs a team name")
+	}
+	if err := applyPreset(); err != nil {
+		return err
+	}
+	team := swap.NormalizeTeam(strings.Join(flags.Args(), " "))
+
+	scheduleRecords, err := loadSchedule(*source, *pluginDir)
+	if err != nil {
+		return err
+	}
+	games := schedule.Rows(scheduleRecords, nil)
+	idx := slices.IndexFunc(games, func(game []string) bool {
+		return swap.NormalizeTeam(game[schedule.HOMETEAM]) == team || swap.NormalizeTeam(game[schedule.AWAYTEAM]) == team
+	})
+	if idx < 0 {
+		return fmt.Errorf("team %s not found in the schedule", team)
+	}
+	division, err := swap.FindDivision(games[idx][schedule.DIVISION])
+	if err != nil {
+		return err
+	}
+
+	var contactList map[string]contacts.Contact
+	if *source != "" {
+		if contactList, err = pluginContacts(*pluginDir, *source); err != nil {
+			return err
+		}
+	} else {
+		contactList, err = teamContacts(false)
+		if err != nil {
+			return err
+		}
+	}
+	if *contactsFile != "" {
+		fileContacts, err := contacts.ReadFile(*contactsFile)
+		if err != nil {
+			return err
+		}
+		if contactList, err = contacts.Merge(contactList, fileContacts, "feed"); err != nil {
+			return err
+		}
+	}
+
+	teams, err := swappableTeams(games, team, division.SwapsRegex)
+	if err != nil {
+		return err
+	}
+
+	fileName := "kickoff-" + strings.ReplaceAll(team, " ", "_") + ".csv"
+	csvFile, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+	writer := csv.NewWriter(csvFile)
+	writer.Write([]string{"Division", "Team", "Coach", "Coach Email", "Manager", "Manager Email", "Other Emails"})
+
+	var emails []string
+	for _, entry := range teams {
+		c := contactList[entry[1]]
+		writer.Write([]string{entry[0], entry[1], c.Coach, c.CoachEmail, c.Manager,
+			c.ManagerEmail, strings.Join(c.Others, ";")})
+		for _, email := range append([]string{c.CoachEmail, c.ManagerEmail}, c.Others...) {
+			if email != "" && !slices.Contains(emails, email) {
+				emails = append(emails, email)
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recorded %d teams in %s to %s\n", len(teams), division.Swaps, fileName)
+	fmt.Println(strings.Join(emails, "; "))
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+ Safe concurrent runs
+
+ Several runs can share the same files, i.e. a scheduled bulk run while a
+ manager searches by hand. Files are written to a temporary file and renamed
+ into place, so a run never reads a half written schedule, cache or tracker.
+ The tracker is also changed by read, modify and write, so it is locked while
+ that happens and the change is made to the latest copy on disk; otherwise
+ one run would throw away the responses the other recorded.
+
+ The lock is a file next to the locked one, created only if it doesn't exist,
+ which works the same on every platform. A lock left behind by a run that
+ crashed is taken over once it is older than LOCK_STALE.
+*/
+
+// Age after which a lock is assumed to be left from a crashed run
+const LOCK_STALE = 2 * time.Minute
+
+// How long to wait for another run to release a lock
+var lockWait = 30 * time.Second
+
+// How often to check if a lock has been released
+const lockPoll = 100 * time.Millisecond
+
+/*
+Lock a file for changing. Returns the function to release the lock, or an
+error if another run holds it for longer than lockWait.
+*/
+func lockFile(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d %s\n", os.Getpid(), time.Now().Format(time.RFC3339))
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		fi, statErr := os.Stat(lockPath)
+		if statErr == nil && time.Since(fi.ModTime()) > LOCK_STALE {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			holder, _ := os.ReadFile(lockPath)
+			return nil, fmt.Errorf("%s is locked by another run (process %s), delete %s if no other run is active",
+				path, strings.Fields(string(holder) + " ?")[0], lockPath)
+		}
+		time.Sleep(lockPoll)
+	}
+}
+
+/*
+Write a file by writing a temporary file in the same directory and renaming
+it over the file, so readers see either the old or the new contents.
+*/
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	return whileWriting(func() error {
+		return writeFile(path, data, perm)
+	})
+}
+
+func writeFile(path string, data []byte, perm fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
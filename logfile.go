@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ Log file
+
+ Run from cron or a scheduled task, nobody sees what the search printed. With
+ -log the searches, the notifications and the emails sent and any error are
+ also written to a log file, so a night without notifications can be
+ explained the next morning with
+
+   go-scheduler logs -n 50
+
+ The log is rotated when it grows past LOG_MAX_BYTES or its first entry is
+ older than LOG_MAX_AGE: go-scheduler.log becomes go-scheduler.log.1, the
+ one before becomes .2, and so on, keeping LOG_KEEP old logs.
+*/
+
+// Log file written with -log and read by the logs sub-command
+const LOG_FILE = "go-scheduler.log"
+
+// Size a log is rotated at
+const LOG_MAX_BYTES = 1 << 20
+
+// Age of the first entry a log is rotated at
+const LOG_MAX_AGE = 7 * 24 * time.Hour
+
+// Number of rotated logs kept
+const LOG_KEEP = 5
+
+// Format of the time at the start of each log entry, as written by the log
+// package with the standard flags
+const LOG_TIME_FORMAT = "2006/01/02 15:04:05"
+
+// Log of what the searches did, discarded unless -log is given
+var eventLog = log.New(io.Discard, "", log.LstdFlags)
+
+// Structure to hold a log file rotated by size and age
+type rotatingLog_t struct {
+	path    string
+	mu      sync.Mutex
+	f       *os.File
+	size    int64
+	started time.Time // time of the first entry in the file
+}
+
+/*
+Open a log file for appending, creating it if needed.
+*/
+func openLog(path string) (*rotatingLog_t, error) {
+	l := &rotatingLog_t{path: path}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+/*
+Open the log file and find its size and the time of its first entry.
+*/
+func (l *rotatingLog_t) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.size, l.started = f, fi.Size(), time.Now()
+	if first, err := os.Open(l.path); err == nil {
+		line, _ := bufio.NewReader(first).ReadString('\n')
+		first.Close()
+		if len(line) >= len(LOG_TIME_FORMAT) {
+			if t, err := time.ParseInLocation(LOG_TIME_FORMAT, line[:len(LOG_TIME_FORMAT)], time.Local); err == nil {
+				l.started = t
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Shift the rotated logs along, dropping the oldest, and start a new log.
+*/
+func (l *rotatingLog_t) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	for n := LOG_KEEP - 1; n >= 1; n-- {
+		err := os.Rename(l.path+"."+strconv.Itoa(n), l.path+"."+strconv.Itoa(n+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+/*
+Write an entry, rotating the log first if it is too big or too old.
+*/
+func (l *rotatingLog_t) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size > 0 && (l.size+int64(len(p)) > LOG_MAX_BYTES || time.Since(l.started) > LOG_MAX_AGE) {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+/*
+Return the last lines of the log, reading back through the rotated logs if
+the current one has fewer lines.
+*/
+func tailLog(path string, lines int) ([]string, error) {
+	var tail []string
+	for n := 0; n <= LOG_KEEP && len(tail) < lines; n++ {
+		name := path
+		if n > 0 {
+			name += "." + strconv.Itoa(n)
+		}
+		data, err := os.ReadFile(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		text := strings.TrimRight(string(data), "\n")
+		if text == "" {
+			continue
+		}
+		entries := strings.Split(text, "\n")
+		tail = append(entries[max(0, len(entries)-(lines-len(tail))):], tail...)
+	}
+	return tail, nil
+}
+
+/*
+Print the last entries of the log.
+
+	logs [-file go-scheduler.log] [-n lines]
+*/
+func logsCommand(args []string) error {
+	flags := flag.NewFlagSet("logs", flag.ExitOnError)
+	file := flags.String("file", LOG_FILE, "Log file written with -log")
+	lines := flags.Int("n", 20, "Number of entries to print")
+	flags.Parse(args)
+
+	tail, err := tailLog(*file, *lines)
+	if err != nil {
+		return err
+	}
+	if len(tail) == 0 {
+		fmt.Printf("Nothing logged to %s, searches log there when run with -log\n", *file)
+	}
+	for _, line := range tail {
+		fmt.Println(line)
+	}
+	return nil
+}
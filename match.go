@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Two-sided matching
+
+ Several managers each submit a list of the games they need to move. Rather
+ than each of them searching the whole schedule on their own, games from the
+ lists are paired with each other: two games are a match when each is a
+ potential match for the other, so both teams get the date they want.
+
+ Unlike bulk rescheduling, both games of a pair want to move, so they swap
+ dates. The games with the fewest options are paired first, and every pair is
+ checked against the schedule as updated by the pairs already assigned.
+*/
+
+// Structure to hold a game that needs to move and its partner
+type need_t struct {
+	game       []string   // game that needs to move
+	candidates [][]string // potential matches for the game
+	partner    []string   // game it is paired with, nil if none found
+}
+
+/*
+Check that the teams in each game are free on the date of the other game.
+*/
+func (busy busy_t) canExchange(g, c []string) bool {
+	return busy.free(c[schedule.DATE], g[schedule.HOMETEAM], g[schedule.AWAYTEAM]) &&
+		busy.free(g[schedule.DATE], c[schedule.HOMETEAM], c[schedule.AWAYTEAM])
+}
+
+/*
+Exchange the dates of the two games.
+*/
+func (busy busy_t) exchange(g, c []string) {
+	busy.play(g[schedule.DATE], -1, g[schedule.HOMETEAM], g[schedule.AWAYTEAM])
+	busy.play(c[schedule.DATE], -1, c[schedule.HOMETEAM], c[schedule.AWAYTEAM])
+	busy.play(c[schedule.DATE], 1, g[schedule.HOMETEAM], g[schedule.AWAYTEAM])
+	busy.play(g[schedule.DATE], 1, c[schedule.HOMETEAM], c[schedule.AWAYTEAM])
+}
+
+/*
+Build the needs for the games, finding the potential matches for each one.
+Games that are missing from the schedule or before the cut off date are
+reported and skipped.
+*/
+func buildNeeds(games [][]string, ids []string, cutOffDate time.Time) ([]need_t, error) {
+	// create a debugger object
+	var debug = debuggo.Debug("buildNeeds")
+
+	index := schedule.IndexByDate(games)
+
+	var needs []need_t
+	for _, id := range ids {
+		s, division, err := swap.New(games, index, id)
+		if err != nil {
+			fmt.Println("Skipping game: ", err)
+			continue
+		}
+
+		gameDate, err := time.Parse(schedule.DATE_FORMAT, s.Date)
+		if err != nil {
+			return nil, err
+		}
+		if gameDate.Before(cutOffDate) {
+			fmt.Println("Game is before the cut off date: ", id)
+			continue
+		}
+
+		swappableRe, err := swap.CompileCached(division.SwapsRegex)
+		if err != nil {
+			return nil, fmt.Errorf("game %s: %w", id, err)
+		}
+		s.FindCandidates(swappableRe, cutOffDate)
+		debug("%s has %d potential matches", id, len(s.Games))
+		needs = append(needs, need_t{game: s.Game, candidates: s.Games})
+	}
+
+	return needs, nil
+}
+
+/*
+Pair the games from the lists of games that need to move with each other.
+The partner of each paired game is stored with it.
+*/
+func pairNeeds(games [][]string, needs []need_t) {
+	// create a debugger object
+	var debug = debuggo.Debug("pairNeeds")
+
+	// Record the potential matches of each game needing a move
+	candidates := make(map[string][]string)
+	for _, need := range needs {
+		for _, c := range need.candidates {
+			candidates[need.game[schedule.GAMEID]] = append(candidates[need.game[schedule.GAMEID]], c[schedule.GAMEID])
+		}
+	}
+
+	// Only keep candidates that also need to move and would accept the swap
+	for i := range needs {
+		id := needs[i].game[schedule.GAMEID]
+		needs[i].candidates = slices.DeleteFunc(slices.Clone(needs[i].candidates), func(c []string) bool {
+			return !slices.Contains(candidates[c[schedule.GAMEID]], id)
+		})
+		debug("%s has %d mutual matches", id, len(needs[i].candidates))
+	}
+
+	slices.SortStableFunc(needs, func(a, b need_t) int {
+		return len(a.candidates) - len(b.candidates)
+	})
+
+	busy := newBusy(games)
+	partner := make(map[string][]string)
+	for i := range needs {
+		g := needs[i].game
+		if partner[g[schedule.GAMEID]] != nil {
+			continue
+		}
+		for _, c := range needs[i].candidates {
+			if partner[c[schedule.GAMEID]] != nil {
+				continue
+			}
+			if !busy.canExchange(g, c) {
+				debug("%s <-> %s << conflicts with earlier pairs", g[schedule.GAMEID], c[schedule.GAMEID])
+				continue
+			}
+			busy.exchange(g, c)
+			partner[g[schedule.GAMEID]] = c
+			partner[c[schedule.GAMEID]] = g
+			break
+		}
+	}
+
+	for i := range needs {
+		needs[i].partner = partner[needs[i].game[schedule.GAMEID]]
+	}
+}
+
+/*
+Pair up the games listed in the files (comma separated) and write the pairs
+to matches.csv, with the contacts labelled when they are redacted.
+*/
+func matchNeeds(games [][]string, fileNames string, cutOffDate time.Time,
+	contactList map[string]contacts.Contact, redact bool) error {
+	// create a debugger object
+	var debug = debuggo.Debug("matchNeeds")
+
+	var ids []string
+	for _, fileName := range strings.Split(fileNames, ",") {
+		list, err := readGameIds(strings.TrimSpace(fileName))
+		if err != nil {
+			return err
+		}
+		for _, id := range list {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	needs, err := buildNeeds(games, ids, cutOffDate)
+	if err != nil {
+		return err
+	}
+	pairNeeds(games, needs)
+
+	outName := "matches.csv"
+	debug("Creating output file: %s", outName)
+	csvFile, err := os.Create(outName)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	writer.Write([]string{"Game ID", "Date", "Home Team", "Away Team",
+		"Matched Game ID", "Matched Date", "Matched Home Team", "Matched Away Team", "Contacts"})
+
+	// Write each pair once, followed by the games left without a partner
+	emails := contactsFor(redact)
+	written := make(map[string]bool)
+	pairs := 0
+	for _, need := range needs {
+		g, c := need.game, need.partner
+		if c == nil || written[g[schedule.GAMEID]] {
+			continue
+		}
+		written[g[schedule.GAMEID]], written[c[schedule.GAMEID]] = true, true
+		pairs++
+		fmt.Printf("%s <-> %s\n", g[schedule.GAMEID], c[schedule.GAMEID])
+		writer.Write([]string{g[schedule.GAMEID], g[schedule.DATE], g[schedule.HOMETEAM], g[schedule.AWAYTEAM],
+			c[schedule.GAMEID], c[schedule.DATE], c[schedule.HOMETEAM], c[schedule.AWAYTEAM],
+			emails(contactList, g[schedule.HOMETEAM], g[schedule.AWAYTEAM], c[schedule.HOMETEAM], c[schedule.AWAYTEAM])})
+	}
+	for _, need := range needs {
+		g := need.game
+		if need.partner != nil {
+			continue
+		}
+		fmt.Printf("%s: no match found\n", g[schedule.GAMEID])
+		writer.Write([]string{g[schedule.GAMEID], g[schedule.DATE], g[schedule.HOMETEAM], g[schedule.AWAYTEAM],
+			"", "", "", "", emails(contactList, g[schedule.HOMETEAM], g[schedule.AWAYTEAM])})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Paired %d of %d games, recorded to %s\n", 2*pairs, len(needs), outName)
+	return nil
+}
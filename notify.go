@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+/*
+ Notifications
+
+ Events during a search (potential matches found, no swaps found, a swap
+ accepted) can be sent to other places, i.e. a team Slack channel or the
+ desktop. The notifiers are configured in notifiers.json as a list of routes,
+ each with the type of notifier, its settings and the events it gets:
+
+	[{"type": "slack", "credential": "slack-webhook", "events": ["candidates"]},
+	 {"type": "desktop", "events": ["accepted", "no-swaps"]},
+	 {"type": "command", "command": "./sms.sh"}]
+
+ A route without events gets all of them. Every matching route is notified,
+ and a notifier that fails is reported without stopping the search.
+
+ Types:
+   slack, teams  incoming webhook, the URL is given as url or the name of a
+                 credential holding it
+   webhook       POST of the event as JSON to the url
+   desktop       desktop notification (notify-send or osascript)
+   email         email to the addresses in to, through the SMTP server
+   command       runs the command with the event name and game id as
+                 arguments and the message on standard input, i.e. a script
+                 that sends an SMS
+*/
+
+// Events sent to the notifiers
+const (
+	EVENT_CANDIDATES = "candidates" // potential matches were found
+	EVENT_NO_SWAPS   = "no-swaps"   // no potential matches were found
+	EVENT_ACCEPTED   = "accepted"   // a swap was accepted
+)
+
+// Structure to hold an event for the notifiers
+type event_t struct {
+	Name   string `json:"event"`
+	GameID string `json:"gameId"`
+	Text   string `json:"text"`
+}
+
+// Interface for sending an event somewhere
+type Notifier interface {
+	Notify(event event_t) error
+}
+
+// Structure to hold a notifier route from the notifiers file
+type route_t struct {
+	Type       string   `json:"type"`
+	URL        string   `json:"url,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+	Command    string   `json:"command,omitempty"`
+	To         []string `json:"to,omitempty"`
+	Events     []string `json:"events,omitempty"`
+}
+
+/*
+Read the notifier routes from a file. No routes are returned if the file
+doesn't exist.
+*/
+func readRoutes(path string) ([]route_t, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var routes []route_t
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, route := range routes {
+		if _, err := route.notifier(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return routes, nil
+}
+
+/*
+Return the notifier for a route.
+*/
+func (route route_t) notifier() (Notifier, error) {
+	switch route.Type {
+	case "slack", "teams":
+		return webhookNotifier{url: route.URL, credential: route.Credential, text: true}, nil
+	case "webhook":
+		return webhookNotifier{url: route.URL, credential: route.Credential}, nil
+	case "desktop":
+		return desktopNotifier{}, nil
+	case "email":
+		if len(route.To) == 0 {
+			return nil, errors.New("email notifier needs addresses to send to")
+		}
+		return emailNotifier{to: route.To}, nil
+	case "command":
+		if route.Command == "" {
+			return nil, errors.New("command notifier needs a command")
+		}
+		return commandNotifier{command: route.Command}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier type %q", route.Type)
+}
+
+/*
+Send the event to every route that gets it. Failures are printed and logged
+rather than returned, a notification isn't worth stopping the search for.
+*/
+func notify(routes []route_t, event event_t) {
+	sent := 0
+	for _, route := range routes {
+		if len(route.Events) > 0 && !slices.Contains(route.Events, event.Name) {
+			continue
+		}
+		notifier, err := route.notifier()
+		if err == nil {
+			err = notifier.Notify(event)
+		}
+		if err != nil {
+			fmt.Printf("Warning: %s notification failed: %v\n", route.Type, err)
+			eventLog.Printf("%s: %s notification of %s failed: %v", event.GameID, route.Type, event.Name, err)
+			continue
+		}
+		eventLog.Printf("%s: sent %s notification of %s", event.GameID, route.Type, event.Name)
+		sent++
+	}
+	if sent == 0 {
+		eventLog.Printf("%s: no notification of %s sent, %d notifiers configured", event.GameID, event.Name, len(routes))
+	}
+}
+
+// Notifier posting to a webhook, text posts only {"text": ...} as Slack and
+// Teams incoming webhooks expect
+type webhookNotifier struct {
+	url        string
+	credential string
+	text       bool
+}
+
+func (notifier webhookNotifier) Notify(event event_t) error {
+	url := notifier.url
+	if notifier.credential != "" {
+		var err error
+		if url, err = credential(notifier.credential); err != nil {
+			return err
+		}
+	}
+	if url == "" {
+		return errors.New("no webhook url")
+	}
+
+	var payload any = event
+	if notifier.text {
+		payload = map[string]string{"text": event.Text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(runContext, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// Notifier showing a desktop notification
+type desktopNotifier struct{}
+
+func (desktopNotifier) Notify(event event_t) error {
+	title := "go-scheduler " + event.GameID
+	if runtime.GOOS == "darwin" {
+		script := fmt.Sprintf("display notification %q with title %q", event.Text, title)
+		return exec.Command("osascript", "-e", script).Run()
+	}
+	return exec.Command("notify-send", title, event.Text).Run()
+}
+
+// Notifier running a command with the message on standard input
+type commandNotifier struct {
+	command string
+}
+
+func (notifier commandNotifier) Notify(event event_t) error {
+	cmd := exec.Command(notifier.command, event.Name, event.GameID)
+	cmd.Stdin = strings.NewReader(event.Text)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	return cmd.Run()
+}
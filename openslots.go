@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Open ice slots
+
+ The city exports its ice allocation (permits) for the association as a CSV.
+ Any permitted slot that has no game scheduled in it is ice the association
+ has booked but isn't using, so the game can be rescheduled into it instead of
+ swapping with another team.
+
+ The permit file needs a header row with Date, Time and Arena columns (in any
+ order). Dates use the same format as the schedule (i.e. 2025-03-01).
+*/
+
+// Structure to hold a permitted ice slot
+type slot_t struct {
+	date  string
+	time  string
+	venue string
+}
+
+/*
+Read the permitted ice slots from the city's CSV export.
+*/
+func readIcePermits(fileName string) ([]slot_t, error) {
+	fi, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer fi.Close()
+
+	records, err := csv.NewReader(fi).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", fileName)
+	}
+
+	// Locate the columns from the header
+	column := func(name string) (int, error) {
+		idx := slices.IndexFunc(records[0], func(h string) bool {
+			return strings.EqualFold(strings.TrimSpace(h), name)
+		})
+		if idx < 0 {
+			return idx, fmt.Errorf("%s has no %s column", fileName, name)
+		}
+		return idx, nil
+	}
+	dateCol, err := column("Date")
+	if err != nil {
+		return nil, err
+	}
+	timeCol, err := column("Time")
+	if err != nil {
+		return nil, err
+	}
+	venueCol, err := column("Arena")
+	if err != nil {
+		return nil, err
+	}
+
+	var slots []slot_t
+	for _, r := range records[1:] {
+		slots = append(slots, slot_t{
+			date:  strings.TrimSpace(r[dateCol]),
+			time:  strings.TrimSpace(r[timeCol]),
+			venue: strings.TrimSpace(r[venueCol]),
+		})
+	}
+	return slots, nil
+}
+
+/*
+Return the start of a permitted slot in the association's location. The slot
+is parsed like a game so the city's times (i.e. 7:00 PM) match the schedule's
+(i.e. 19:00).
+*/
+func (slot slot_t) start() (time.Time, error) {
+	return schedule.Start([]string{schedule.GAMEID: slot.venue, schedule.DATE: slot.date, schedule.TIME: slot.time})
+}
+
+/*
+Return the key of a slot in the ice used by the schedule, its start and venue.
+*/
+func usedKey(start time.Time, venue string) string {
+	return fmt.Sprintf("%d|%s", start.Unix(), strings.ToUpper(strings.TrimSpace(venue)))
+}
+
+/*
+Return the permitted slots that are empty in the schedule and after the cut
+off date.
+*/
+func emptySlots(games [][]string, slots []slot_t, cutOffDate time.Time) []slot_t {
+	// create a debugger object
+	var debug = debuggo.Debug("emptySlots")
+
+	used := make(map[string]bool)
+	for _, game := range games {
+		start, err := schedule.Start(game)
+		if err != nil {
+			debug("%v << %v", game, err)
+			continue
+		}
+		used[usedKey(start, game[schedule.VENUE])] = true
+	}
+
+	var empty []slot_t
+	for _, slot := range slots {
+		start, err := slot.start()
+		if err != nil {
+			debug("%v << %v", slot, err)
+			continue
+		}
+		if start.Before(cutOffDate) {
+			continue
+		}
+		if used[usedKey(start, slot.venue)] {
+			continue
+		}
+		empty = append(empty, slot)
+	}
+	return empty
+}
+
+/*
+Return the permitted slots that are empty in the schedule, after the cut off
+date, and on a day when neither team in the swap is playing.
+*/
+func findOpenSlots(games [][]string, slots []slot_t, s swap.Swap, cutOffDate time.Time) []slot_t {
+	// create a debugger object
+	var debug = debuggo.Debug("findOpenSlots")
+
+	var teamDates []string
+	for _, game := range games {
+		if slices.Contains(game, s.Home) || slices.Contains(game, s.Away) {
+			teamDates = append(teamDates, game[schedule.DATE])
+		}
+	}
+
+	var open []slot_t
+	for _, slot := range emptySlots(games, slots, cutOffDate) {
+		if slices.Contains(teamDates, slot.date) {
+			debug("%v << swapping team playing", slot)
+			continue
+		}
+		open = append(open, slot)
+	}
+	return open
+}
+
+/*
+Write the open ice slots available for the swap game to <gameId>-slots.csv.
+*/
+func writeOpenSlots(s swap.Swap, permitFile string, cutOffDate time.Time) error {
+	// create a debugger object
+	var debug = debuggo.Debug("writeOpenSlots")
+
+	slots, err := readIcePermits(permitFile)
+	if err != nil {
+		return err
+	}
+	open := findOpenSlots(s.Games, slots, s, cutOffDate)
+
+	fileName := s.GameID + "-slots.csv"
+	debug("Creating open slots file: %s", fileName)
+	csvFile, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	writer.Write([]string{"Date", "Time", "Arena"})
+	for _, slot := range open {
+		writer.Write([]string{slot.date, slot.time, slot.venue})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recorded %d open ice slots to %s\n", len(open), fileName)
+	return nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Output formats
+
+ The potential matches from a search are collected into a report which is
+ written by one of the registered output writers, selected with -format.
+ Each writer produces a complete file so formats which aren't row based
+ (i.e. JSON or HTML) can be added alongside CSV.
+*/
+
+// Structure to hold the results of a search
+type report_t struct {
+	swap      swap.Swap                   // swap searched for, games are the potential matches
+	division  swap.Division               // division of the game being swapped
+	contacts  map[string]contacts.Contact // team contacts by team name
+	notes     map[string][]note_t         // tracker notes by potential match game id
+	responses map[string]string           // responses from the previous report by game id
+	extra     []string                    // names of the extra schedule columns to include
+	columns   []string                    // keys of the columns to output, all if empty
+	summary   bool                        // include the summary, in its own file for CSV
+	redact    bool                        // label the contacts instead of giving their emails
+	warnings  map[string][]string         // problems with the potential matches by game id
+	scores    map[string]float64          // scores of the potential matches by game id
+	tiers     map[string]string           // strong, possible or long-shot by game id
+	distances map[string]float64          // km to the arenas by name, nil without a home arena
+	rates     map[string]float64          // share of contacts replied to by normalized team name
+	replyDays map[string]float64          // average days to reply by normalized team name
+	curfew    string                      // latest time the team's games can end, HH:MM
+}
+
+// Interface for writing a report in an output format
+type OutputWriter interface {
+	// File extension used for the output, including the dot
+	Ext() string
+	// Write the report
+	Write(w io.Writer, report report_t) error
+}
+
+// Output writers by format name
+var outputWriters = map[string]OutputWriter{
+	"board": boardOutput{},
+	"csv":   csvOutput{},
+	"html":  htmlOutput{},
+	"ics":   icsOutput{},
+	"json":  jsonOutput{},
+	"xlsx":  xlsxOutput{},
+}
+
+/*
+Return the output writer for a format, or an error listing the formats
+available.
+*/
+func outputWriter(format string) (OutputWriter, error) {
+	writer, ok := outputWriters[strings.ToLower(format)]
+	if !ok {
+		var formats []string
+		for name := range outputWriters {
+			formats = append(formats, name)
+		}
+		slices.Sort(formats)
+		return nil, fmt.Errorf("unknown output format %s (available: %s)", format,
+			strings.Join(formats, ", "))
+	}
+	return writer, nil
+}
+
+// Columns in the report of potential matches, the extra schedule columns are
+// added after the away team
+var reportHeader = []string{"Division", "Game ID", "Date", "Time", "Arena",
+	"Home Team", "Away Team", "Contacts", "Response", "Notes", "Warnings", "Score", "Tier"}
+
+// Keys for selecting the report columns with -columns, in the same order as
+// the header. The extra schedule columns use their field names as keys.
+var reportKeys = []string{"division", "id", "date", "time", "venue",
+	"home", "away", "contacts", "response", "notes", "warnings", "score", "tier"}
+
+/*
+Return the keys of the report columns including any extra columns.
+*/
+func (report report_t) keys() []string {
+	keys := slices.Clone(reportKeys[:schedule.AWAYTEAM+1])
+	for _, name := range report.extra {
+		keys = append(keys, strings.ToLower(name))
+	}
+	return append(keys, reportKeys[schedule.AWAYTEAM+1:]...)
+}
+
+/*
+Parse a comma separated list of column keys and check they are all known.
+"arena" is accepted for the venue.
+*/
+func parseColumns(list string, extra []string) ([]string, error) {
+	known := report_t{extra: extra}.keys()
+	var columns []string
+	for _, key := range strings.Split(list, ",") {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "arena" {
+			key = "venue"
+		}
+		if !slices.Contains(known, key) {
+			return nil, fmt.Errorf("unknown column %s (available: %s)", key,
+				strings.Join(known, ", "))
+		}
+		columns = append(columns, key)
+	}
+	return columns, nil
+}
+
+/*
+Return the header and rows of the report, limited to the selected columns.
+*/
+func (report report_t) table() ([]string, [][]string) {
+	header, rows := report.header(), report.rows()
+	if len(report.columns) == 0 {
+		return header, rows
+	}
+
+	keys := report.keys()
+	var idx []int
+	for _, key := range report.columns {
+		idx = append(idx, slices.Index(keys, key))
+	}
+	pick := func(row []string) []string {
+		picked := make([]string, len(idx))
+		for i, j := range idx {
+			picked[i] = row[j]
+		}
+		return picked
+	}
+
+	selected := make([][]string, len(rows))
+	for i, row := range rows {
+		selected[i] = pick(row)
+	}
+	return pick(header), selected
+}
+
+/*
+Return the header of the report including any extra columns.
+*/
+func (report report_t) header() []string {
+	header := slices.Clone(reportHeader[:schedule.AWAYTEAM+1])
+	for _, name := range report.extra {
+		header = append(header, strings.ToUpper(name[:1])+name[1:])
+	}
+	return append(header, reportHeader[schedule.AWAYTEAM+1:]...)
+}
+
+/*
+Return the function that lists the contacts of teams in a file: their emails,
+or labels when the contacts are redacted so the file can be shared.
+*/
+func contactsFor(redact bool) func(map[string]contacts.Contact, ...string) string {
+	if redact {
+		return contacts.Labels
+	}
+	return contacts.Emails
+}
+
+/*
+Return the rows of the report, one for each potential match. The contacts are
+the emails for both teams in the swap and both teams in the match, followed by
+the response from the previous report, any notes about the match, any
+warnings, the score and its tier.
+*/
+func (report report_t) rows() [][]string {
+	s := report.swap
+	rows := make([][]string, 0, len(s.Games))
+	for _, g := range s.Games {
+		row := slices.Clone(g[:schedule.AWAYTEAM+1])
+		if len(report.extra) > 0 {
+			row = append(row, g[schedule.AWAYTEAM+1:schedule.AWAYTEAM+1+len(report.extra)]...)
+		}
+		emails := contactsFor(report.redact)
+		score := ""
+		if value, ok := report.scores[g[schedule.GAMEID]]; ok {
+			score = strconv.FormatFloat(value, 'f', 1, 64)
+		}
+		row = append(row, emails(report.contacts, s.Home, s.Away,
+			g[schedule.HOMETEAM], g[schedule.AWAYTEAM]), report.responses[g[schedule.GAMEID]],
+			formatNotes(report.notes[g[schedule.GAMEID]]), strings.Join(report.warnings[g[schedule.GAMEID]], "; "), score,
+			report.tiers[g[schedule.GAMEID]])
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// CSV output
+type csvOutput struct{}
+
+func (csvOutput) Ext() string { return ".csv" }
+
+func (csvOutput) Write(w io.Writer, report report_t) error {
+	writer := csv.NewWriter(w)
+	header, rows := report.table()
+	writer.Write(header)
+	writer.WriteAll(rows)
+	return writer.Error()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Overlapping searches
+
+ A team needing several swaps searches for each game separately, and the same
+ opponent can turn up as a potential match for all of them. Asking one team
+ for three swaps at once wastes their goodwill, so the potential matches of
+ each search are kept in the tracker and a match is flagged when one of its
+ teams was proposed, or contacted, for another open swap. A swap is open until
+ a match is accepted or its game has been played.
+*/
+
+// Structure to hold another open swap a team is already part of
+type asked_t struct {
+	gameId    string // the other swap game id
+	contacted bool   // true if the team was contacted, not only proposed
+}
+
+/*
+Record the potential matches reported for a swap game, replacing the ones
+from the last search.
+*/
+func (tracker *tracker_t) propose(gameId string, matchIds []string) {
+	if tracker.Proposed == nil {
+		tracker.Proposed = make(map[string][]string)
+	}
+	tracker.Proposed[gameId] = matchIds
+}
+
+/*
+Return the other open swaps each team has been proposed or contacted for, by
+normalized team name. Swaps accepted or with their game before today are
+closed.
+*/
+func otherSwaps(tracker *tracker_t, games [][]string, gameId, today string) map[string][]asked_t {
+	byId := make(map[string][]string)
+	for _, game := range games {
+		byId[game[schedule.GAMEID]] = game
+	}
+
+	asked := make(map[string][]asked_t)
+	add := func(otherId, matchId string, contacted bool) {
+		match, ok := byId[matchId]
+		if !ok {
+			return
+		}
+		for _, team := range []string{match[schedule.HOMETEAM], match[schedule.AWAYTEAM]} {
+			team = swap.NormalizeTeam(team)
+			i := slices.IndexFunc(asked[team], func(a asked_t) bool { return a.gameId == otherId })
+			if i < 0 {
+				asked[team] = append(asked[team], asked_t{gameId: otherId, contacted: contacted})
+			} else if contacted {
+				asked[team][i].contacted = true
+			}
+		}
+	}
+
+	ids := slices.Sorted(maps.Keys(tracker.Searches))
+	for _, otherId := range ids {
+		if otherId == gameId || tracker.Accepted[otherId] != "" {
+			continue
+		}
+		if game, ok := byId[otherId]; ok && game[schedule.DATE] < today {
+			continue
+		}
+		for _, matchId := range tracker.Proposed[otherId] {
+			add(otherId, matchId, false)
+		}
+		for matchId, response := range tracker.Responses[otherId] {
+			if strings.EqualFold(response, "contacted") {
+				add(otherId, matchId, true)
+			}
+		}
+	}
+	return asked
+}
+
+/*
+Return warnings, by potential match game id, for the matches with a team
+already proposed or contacted for another open swap.
+*/
+func overlapWarnings(s swap.Swap, asked map[string][]asked_t) map[string][]string {
+	warnings := make(map[string][]string)
+	for _, game := range s.Games {
+		for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+			for _, other := range asked[swap.NormalizeTeam(team)] {
+				verb := "also proposed"
+				if other.contacted {
+					verb = "already asked"
+				}
+				warnings[game[schedule.GAMEID]] = append(warnings[game[schedule.GAMEID]],
+					fmt.Sprintf("%s %s for swap %s", team, verb, other.gameId))
+			}
+		}
+	}
+	return warnings
+}
+
+/*
+Return the teams already contacted for another open swap.
+*/
+func contactedTeams(asked map[string][]asked_t) []string {
+	var teams []string
+	for team, others := range asked {
+		if slices.ContainsFunc(others, func(a asked_t) bool { return a.contacted }) {
+			teams = append(teams, team)
+		}
+	}
+	slices.Sort(teams)
+	return teams
+}
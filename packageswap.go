@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Two-game package swaps
+
+ A package swap is used when both meetings between the same two teams (each
+ team's home game against the other) need to move. Instead of looking for two
+ unrelated swaps, look for another pair of teams that also meet twice and
+ exchange both games with them:
+
+   swap game    (A vs B on D1) <-> first candidate  (X vs Y on E1)
+   package game (B vs A on D2) <-> second candidate (Y vs X on E2)
+
+ Both directions are validated against the full schedule: A and B must be
+ free on E1 and E2, and X and Y must be free on D1 and D2.
+*/
+
+// Structure to hold a two-game package swap
+type package_t struct {
+	first  []string // candidate game exchanged with the swap game
+	second []string // candidate game exchanged with the package game
+}
+
+/*
+Return the pair of teams playing in a game as a key that does not depend on
+which team is at home.
+*/
+func pairKey(game []string) string {
+	home := swap.NormalizeTeam(game[schedule.HOMETEAM])
+	away := swap.NormalizeTeam(game[schedule.AWAYTEAM])
+	if away < home {
+		home, away = away, home
+	}
+	return home + "|" + away
+}
+
+/*
+Find candidate package swaps for the swap game and the package game among the
+potential matches. The potential matches have been through the same filters
+as a single swap, the schedule must contain every game (not just the
+swappable ones) so that conflicts with other divisions are detected.
+*/
+func findPackages(games, candidates [][]string, first, second []string) []package_t {
+	// create a debugger object
+	var debug = debuggo.Debug("findPackages")
+
+	// Map each date to the teams playing on it and the ids of their games
+	busy := make(map[string]map[string][]string)
+	for _, game := range games {
+		date := game[schedule.DATE]
+		if busy[date] == nil {
+			busy[date] = make(map[string][]string)
+		}
+		for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+			team = swap.NormalizeTeam(team)
+			busy[date][team] = append(busy[date][team], game[schedule.GAMEID])
+		}
+	}
+
+	// Check that none of the teams have a game on the date, ignoring the
+	// games which are being moved as part of the package
+	free := func(teams []string, date string, moving []string) bool {
+		for _, team := range teams {
+			for _, id := range busy[date][swap.NormalizeTeam(team)] {
+				if !slices.Contains(moving, id) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	// Group the potential matches by the pair of teams playing
+	ourPair := pairKey(first)
+	pairs := make(map[string][][]string)
+	var order []string
+	for _, game := range candidates {
+		key := pairKey(game)
+		if key == ourPair {
+			continue
+		}
+		if _, ok := pairs[key]; !ok {
+			order = append(order, key)
+		}
+		pairs[key] = append(pairs[key], game)
+	}
+
+	ours := []string{first[schedule.HOMETEAM], first[schedule.AWAYTEAM]}
+	var packages []package_t
+	for _, key := range order {
+		games := pairs[key]
+		for _, c1 := range games {
+			for _, c2 := range games {
+				dates := []string{first[schedule.DATE], second[schedule.DATE], c1[schedule.DATE], c2[schedule.DATE]}
+				slices.Sort(dates)
+				if len(slices.Compact(dates)) != 4 {
+					// all four games must be on different days
+					continue
+				}
+
+				moving := []string{first[schedule.GAMEID], second[schedule.GAMEID], c1[schedule.GAMEID], c2[schedule.GAMEID]}
+				theirs := []string{c1[schedule.HOMETEAM], c1[schedule.AWAYTEAM]}
+				if !free(ours, c1[schedule.DATE], moving) || !free(ours, c2[schedule.DATE], moving) {
+					debug("%s/%s << swapping teams not free", c1[schedule.GAMEID], c2[schedule.GAMEID])
+					continue
+				}
+				if !free(theirs, first[schedule.DATE], moving) || !free(theirs, second[schedule.DATE], moving) {
+					debug("%s/%s << candidate teams not free", c1[schedule.GAMEID], c2[schedule.GAMEID])
+					continue
+				}
+				packages = append(packages, package_t{first: c1, second: c2})
+			}
+		}
+	}
+
+	return packages
+}
+
+/*
+Search the potential matches for two-game package swaps and write them to
+<gameId>-<packageId>.csv. The package game has to be swappable too, so it
+can't be before the cut off date or have a score recorded. Each package is
+written as two rows, one for each game being exchanged, with the contacts
+labelled when they are redacted.
+*/
+func writePackages(s swap.Swap, games [][]string, packageId string, cutOffDate time.Time,
+	contactList map[string]contacts.Contact, redact bool) error {
+	// create a debugger object
+	var debug = debuggo.Debug("writePackages")
+
+	// Find both games in the schedule
+	var first, second []string
+	for _, game := range games {
+		switch game[schedule.GAMEID] {
+		case s.GameID:
+			first = game
+		case packageId:
+			second = game
+		}
+	}
+	if first == nil || second == nil {
+		return fmt.Errorf("could not find games %s and %s in the schedule", s.GameID, packageId)
+	}
+	if pairKey(first) != pairKey(second) {
+		return fmt.Errorf("game %s is not between %s and %s", packageId, s.Home, s.Away)
+	}
+	if schedule.Scored(second) {
+		return fmt.Errorf("game %s has a score recorded, it has already been played", packageId)
+	}
+	packageDate, err := time.Parse(schedule.DATE_FORMAT, second[schedule.DATE])
+	if err != nil {
+		return err
+	}
+	if packageDate.Before(cutOffDate) {
+		return fmt.Errorf("game %s is before the cut off date of %s", packageId, cutOffDate.Format(schedule.DATE_FORMAT))
+	}
+	fmt.Println("Package date: ", second[schedule.DATE])
+
+	packages := findPackages(games, s.Games, first, second)
+
+	fileName := s.GameID + "-" + packageId + ".csv"
+	debug("Creating output file: %s", fileName)
+	csvFile, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+
+	writer := csv.NewWriter(csvFile)
+	writer.Write([]string{"Package", "Swap For", "Division", "Game ID", "Date", "Time",
+		"Arena", "Home Team", "Away Team", "Contacts"})
+
+	for i, p := range packages {
+		for _, row := range []struct {
+			swapFor string
+			game    []string
+		}{{s.GameID, p.first}, {packageId, p.second}} {
+			g := row.game
+			fmt.Println(strings.Join(g, ","))
+			writer.Write(append(append([]string{fmt.Sprint(i + 1), row.swapFor}, g[:schedule.AWAYTEAM+1]...),
+				contactsFor(redact)(contactList, s.Home, s.Away, g[schedule.HOMETEAM], g[schedule.AWAYTEAM])))
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recorded %d potential packages to %s\n", len(packages), fileName)
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Data source plugins
+
+ A plugin is an executable in the plugins directory which supplies the
+ schedule and team contacts in place of TTM, so other leagues or file formats
+ can be supported without changing this program. It is selected by its file
+ name without the extension (i.e. plugins/hockeycanada.exe -> hockeycanada).
+
+ The plugin is run once for each kind of data. A JSON request is written to
+ its stdin:
+
+   {"kind": "schedule"}   or   {"kind": "contacts"}
+
+ and it must write a JSON array to stdout using the same fields as the
+ decoded TTM data (see schedule.Record and contacts.Contact). A non-zero exit
+ status is treated as an error and anything written to stderr is passed
+ through to the user.
+*/
+
+// Structure for the request sent to a plugin
+type pluginRequest struct {
+	Kind string `json:"kind"`
+}
+
+/*
+Find the executable for the named plugin in the plugins directory. The names
+of the available plugins are listed in the error if it isn't found.
+*/
+func findPlugin(dir, name string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		base := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if base == name {
+			return filepath.Join(dir, entry.Name()), nil
+		}
+		names = append(names, base)
+	}
+	return "", fmt.Errorf("plugin %s not found in %s (available: %s)", name, dir,
+		strings.Join(names, ", "))
+}
+
+/*
+Run the plugin for a kind of data and decode its response into out.
+*/
+func runPlugin(dir, name, kind string, out any) error {
+	// create a debugger object
+	var debug = debuggo.Debug("runPlugin")
+
+	path, err := findPlugin(dir, name)
+	if err != nil {
+		return err
+	}
+
+	request, err := json.Marshal(pluginRequest{Kind: kind})
+	if err != nil {
+		return err
+	}
+
+	debug("Running %s for %s", path, kind)
+	cmd := exec.Command(path)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("plugin %s failed: %w", name, err)
+	}
+
+	if err := json.Unmarshal(output, out); err != nil {
+		return fmt.Errorf("plugin %s returned invalid %s: %w", name, kind, err)
+	}
+	return nil
+}
+
+/*
+Get the schedule from a plugin.
+*/
+func pluginSchedule(dir, name string) ([]schedule.Record, error) {
+	var scheduleRecords []schedule.Record
+	if err := runPlugin(dir, name, "schedule", &scheduleRecords); err != nil {
+		return nil, err
+	}
+	return scheduleRecords, nil
+}
+
+/*
+Get the schedule from the named plugin, or download it from TTM if no plugin
+is given.
+*/
+func loadSchedule(source, dir string) ([]schedule.Record, error) {
+	if source != "" {
+		return pluginSchedule(dir, source)
+	}
+	return downloadSchedule(homeOrg.ID)
+}
+
+/*
+Get the team contacts from a plugin.
+*/
+func pluginContacts(dir, name string) (map[string]contacts.Contact, error) {
+	var contactList []contacts.Contact
+	if err := runPlugin(dir, name, "contacts", &contactList); err != nil {
+		return nil, err
+	}
+	return contacts.Map(contactList), nil
+}
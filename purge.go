@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Purging personal data
+
+ Association privacy policies limit how long volunteer contact information is
+ kept. The purge sub-command deletes the saved contacts, cached responses,
+ reports and email drafts, and tracker entries older than the retention
+ period, i.e.
+
+   go-scheduler purge -days 180
+
+ Tracker entries for a swap game are as old as its first search. Entries for
+ a game with no search recorded are kept, their age isn't known. The files
+ for a swap game (<gameId>.csv, <gameId>-emails, ...) are deleted when they
+ were last written before the cut off.
+*/
+
+/*
+Delete old saved contacts, cached responses, reports, email drafts and
+tracker data.
+
+	purge [-tracker file] [-contacts file] [-dir reports] [-days N]
+*/
+func purgeCommand(args []string) error {
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	trackerFile := flags.String("tracker", "tracker.json", "File the swap tracker is stored in")
+	contactsFile := flags.String("contacts", "contacts.json", "File the team contacts were saved to")
+	reportsDir := flags.String("dir", ".", "Directory the reports and email drafts were written to")
+	days := flags.Int("days", 365, "Number of days personal data is kept")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler purge [options]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *days < 0 {
+		return fmt.Errorf("days can't be negative")
+	}
+	cutOff := time.Now().AddDate(0, 0, -*days)
+
+	// Saved contacts are downloaded again when needed
+	if fi, err := os.Stat(*contactsFile); err == nil && fi.ModTime().Before(cutOff) {
+		if err := os.Remove(*contactsFile); err != nil {
+			return err
+		}
+		fmt.Println("Deleted", *contactsFile)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// Cached responses include the contacts
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, entry := range entries {
+		fi, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if fi.ModTime().Before(cutOff) {
+			if err := os.Remove(filepath.Join(cacheDir, entry.Name())); err != nil {
+				return err
+			}
+			fmt.Println("Deleted", filepath.Join(cacheDir, entry.Name()))
+		}
+	}
+
+	// The game ids are collected first, the files of games whose tracker
+	// entries are purged are deleted too
+	var gameIds []string
+	removed := 0
+	_, err = updateTracker(*trackerFile, func(tracker *tracker_t) {
+		gameIds = tracker.gameIds()
+		removed = tracker.purge(cutOff)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d tracker entries from before %s\n", removed, cutOff.Format(schedule.DATE_FORMAT))
+
+	// Reports, summaries, shortlists and email drafts all hold contact
+	// emails
+	for _, gameId := range gameIds {
+		if err := purgeGameFiles(*reportsDir, gameId, cutOff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Return the ids of the swap games in the tracker, sorted.
+*/
+func (tracker *tracker_t) gameIds() []string {
+	ids := make(map[string]bool)
+	for _, keys := range [][]string{
+		slices.Collect(maps.Keys(tracker.Notes)),
+		slices.Collect(maps.Keys(tracker.Responses)),
+		slices.Collect(maps.Keys(tracker.Replies)),
+		slices.Collect(maps.Keys(tracker.Searches)),
+		slices.Collect(maps.Keys(tracker.Accepted)),
+		slices.Collect(maps.Keys(tracker.Proposed)),
+	} {
+		for _, id := range keys {
+			ids[id] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(ids))
+}
+
+/*
+Delete the files and directories written for a swap game, i.e. <gameId>.csv
+or <gameId>-emails, that were last written before the cut off.
+*/
+func purgeGameFiles(dir, gameId string, cutOff time.Time) error {
+	var paths []string
+	for _, pattern := range []string{gameId + ".*", gameId + "-*"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return err
+		}
+		paths = append(paths, matches...)
+	}
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !fi.ModTime().Before(cutOff) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		fmt.Println("Deleted", path)
+	}
+	return nil
+}
+
+/*
+Remove the notes, declines and expired snoozes from before the cut off, and
+the responses, proposed matches, acceptance and search of swap games first
+searched for before it. Games with no search recorded are kept. Returns the
+number of entries removed.
+*/
+func (tracker *tracker_t) purge(cutOff time.Time) int {
+	removed := 0
+	for gameId, matches := range tracker.Notes {
+		for matchId, notes := range matches {
+			before := len(notes)
+			notes = slices.DeleteFunc(notes, func(note note_t) bool {
+				return note.Date.Before(cutOff)
+			})
+			removed += before - len(notes)
+			if len(notes) == 0 {
+				delete(matches, matchId)
+			} else {
+				matches[matchId] = notes
+			}
+		}
+		if len(matches) == 0 {
+			delete(tracker.Notes, gameId)
+		}
+	}
+
+	for gameId, searched := range tracker.Searches {
+		if !searched.Before(cutOff) {
+			continue
+		}
+		removed += len(tracker.Responses[gameId]) + len(tracker.Proposed[gameId]) + 1
+		delete(tracker.Responses, gameId)
+		delete(tracker.Replies, gameId)
+		delete(tracker.Proposed, gameId)
+		if _, ok := tracker.Accepted[gameId]; ok {
+			removed++
+			delete(tracker.Accepted, gameId)
+		}
+		delete(tracker.Searches, gameId)
+	}
+
+	before := len(tracker.Declines)
+	tracker.Declines = slices.DeleteFunc(tracker.Declines, func(decline decline_t) bool {
+		return decline.Date.Before(cutOff)
+	})
+	removed += before - len(tracker.Declines)
+
+	before = len(tracker.Snoozes)
+	tracker.Snoozes = slices.DeleteFunc(tracker.Snoozes, func(snooze snooze_t) bool {
+		return snooze.Until < cutOff.Format(schedule.DATE_FORMAT)
+	})
+	removed += before - len(tracker.Snoozes)
+	return removed
+}
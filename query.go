@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Schedule queries
+
+ The query sub-command loads the schedule and reads filter expressions from
+ the user, printing the games that match each one:
+
+   query> division =~ "U13" && date < 2025-03-15 && venue == "Potvin"
+
+ Fields are the schedule columns (division, id, date, time, venue, home, away
+ and any extra columns) plus team, which matches either the home or the away
+ team. Values are compared as text, which works for dates and times as they
+ are zero padded. =~ and !~ match a regular expression, ignoring case.
+ Conditions are combined with &&, || and !, and grouped with parentheses.
+*/
+
+// Compiled query expression, returns true if the game matches
+type predicate func(game []string) bool
+
+// Token in a query expression
+type qtoken struct {
+	text   string
+	quoted bool // quoted string values are never operators
+}
+
+// Operators in the order they are matched, longest first
+var queryOperators = []string{"&&", "||", "==", "!=", "=~", "!~", "<=", ">=", "<", ">", "!", "(", ")"}
+
+/*
+Split a query expression into tokens.
+*/
+func tokenizeQuery(expr string) ([]qtoken, error) {
+	var tokens []qtoken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '"':
+			// Quoted value, \" can be used for a quote
+			var text strings.Builder
+			i++
+			for i < len(expr) && expr[i] != '"' {
+				if expr[i] == '\\' && i+1 < len(expr) {
+					i++
+				}
+				text.WriteByte(expr[i])
+				i++
+			}
+			if i >= len(expr) {
+				return nil, fmt.Errorf("missing closing quote")
+			}
+			i++
+			tokens = append(tokens, qtoken{text: text.String(), quoted: true})
+		default:
+			op := ""
+			for _, o := range queryOperators {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op != "" {
+				tokens = append(tokens, qtoken{text: op})
+				i += len(op)
+				continue
+			}
+
+			// Bare word, up to white space or an operator
+			start := i
+			for i < len(expr) && !unicode.IsSpace(rune(expr[i])) && !strings.ContainsRune("&|=!<>()\"", rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, qtoken{text: expr[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+// Parser for query expressions
+type queryParser struct {
+	tokens []qtoken
+	pos    int
+	fields map[string][]int // column indexes by field name
+}
+
+/*
+Return the next token without consuming it, or an empty token at the end.
+*/
+func (p *queryParser) peek() qtoken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return qtoken{}
+}
+
+/*
+Consume the next token if it is the operator.
+*/
+func (p *queryParser) accept(op string) bool {
+	if t := p.peek(); !t.quoted && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+/*
+Compile a query expression for the schedule columns.
+*/
+func parseQuery(expr string, fields map[string][]int) (predicate, error) {
+	tokens, err := tokenizeQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens, fields: fields}
+	pred, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return pred, nil
+}
+
+func (p *queryParser) or() (predicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(game []string) bool { return l(game) || right(game) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) and() (predicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(game []string) bool { return l(game) && right(game) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) unary() (predicate, error) {
+	if p.accept("!") {
+		pred, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(game []string) bool { return !pred(game) }, nil
+	}
+	if p.accept("(") {
+		pred, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return pred, nil
+	}
+	return p.comparison()
+}
+
+func (p *queryParser) comparison() (predicate, error) {
+	field := p.peek()
+	if field.text == "" {
+		return nil, fmt.Errorf("expression is incomplete")
+	}
+	idx, ok := p.fields[strings.ToLower(field.text)]
+	if field.quoted || !ok {
+		return nil, fmt.Errorf("unknown field %q", field.text)
+	}
+	p.pos++
+
+	op := p.peek()
+	if op.quoted || !slices.Contains([]string{"==", "!=", "=~", "!~", "<", "<=", ">", ">="}, op.text) {
+		return nil, fmt.Errorf("expected a comparison after %s", field.text)
+	}
+	p.pos++
+
+	value := p.peek()
+	if value.text == "" && !value.quoted {
+		return nil, fmt.Errorf("expected a value after %s", op.text)
+	}
+	p.pos++
+
+	var compare func(string) bool
+	switch op.text {
+	case "==":
+		compare = func(s string) bool { return strings.EqualFold(s, value.text) }
+	case "!=":
+		compare = func(s string) bool { return !strings.EqualFold(s, value.text) }
+	case "=~", "!~":
+		re, err := regexp.Compile("(?i)" + value.text)
+		if err != nil {
+			return nil, err
+		}
+		want := op.text == "=~"
+		compare = func(s string) bool { return re.MatchString(s) == want }
+	case "<":
+		compare = func(s string) bool { return s < value.text }
+	case "<=":
+		compare = func(s string) bool { return s <= value.text }
+	case ">":
+		compare = func(s string) bool { return s > value.text }
+	case ">=":
+		compare = func(s string) bool { return s >= value.text }
+	}
+
+	// Fields with several columns (team) match if any of them do, except
+	// for the negative comparisons which must hold for all of them
+	negative := op.text == "!=" || op.text == "!~"
+	return func(game []string) bool {
+		for _, i := range idx {
+			if compare(game[i]) != negative {
+				return !negative
+			}
+		}
+		return negative
+	}, nil
+}
+
+/*
+Return the column indexes for the query field names.
+*/
+func queryFields(extra []string) map[string][]int {
+	fields := map[string][]int{
+		"team":  {schedule.HOMETEAM, schedule.AWAYTEAM},
+		"arena": {schedule.VENUE},
+	}
+	for i, key := range reportKeys[:schedule.AWAYTEAM+1] {
+		fields[key] = []int{i}
+	}
+	for i, name := range extra {
+		fields[strings.ToLower(name)] = []int{schedule.AWAYTEAM + 1 + i}
+	}
+	return fields
+}
+
+/*
+Load the schedule and run queries against it until the input ends or the
+user quits.
+
+	query [-source name] [-plugins dir]
+*/
+func queryCommand(args []string) error {
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+	source := flags.String("source", "",
+		"Name of the plugin to get the schedule from instead of TTM")
+	pluginDir := flags.String("plugins", "plugins", "Directory containing data source plugins")
+	orgFlags(flags)
+	flags.Parse(args)
+
+	scheduleRecords, err := loadSchedule(*source, *pluginDir)
+	if err != nil {
+		return err
+	}
+	extra := schedule.ExtraColumns(scheduleRecords)
+	games := schedule.Rows(scheduleRecords, extra)
+	fields := queryFields(extra)
+
+	fmt.Printf("Loaded %d games. Fields: %s\n", len(games),
+		strings.Join(slices.Sorted(maps.Keys(fields)), ", "))
+	fmt.Println("Enter a query, or quit to exit")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("query> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return scanner.Err()
+		}
+		expr := strings.TrimSpace(scanner.Text())
+		switch expr {
+		case "":
+			continue
+		case "quit", "exit":
+			return nil
+		}
+
+		match, err := parseQuery(expr, fields)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			continue
+		}
+		count := 0
+		for _, game := range games {
+			if match(game) {
+				fmt.Println(strings.Join(game, ","))
+				count++
+			}
+		}
+		fmt.Printf("%d games\n", count)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Repeat matchups
+
+ Some divisions limit how often the same two teams can meet. Swapping moves
+ the two teams of each game to the other game's date, so a potential match is
+ flagged when either pair would then play each other again within the window.
+*/
+
+/*
+Return the date of another game between the two teams within days of the
+date, skipping the game being moved, or an empty string if there isn't one.
+*/
+func meetsWithin(index schedule.Index, teamA, teamB, date string, days int, skipId string) string {
+	d, err := time.Parse(schedule.DATE_FORMAT, date)
+	if err != nil {
+		return ""
+	}
+	a, b := swap.NormalizeTeam(teamA), swap.NormalizeTeam(teamB)
+	for offset := -days; offset <= days; offset++ {
+		day := d.AddDate(0, 0, offset).Format(schedule.DATE_FORMAT)
+		for _, game := range index[day] {
+			if game[schedule.GAMEID] == skipId {
+				continue
+			}
+			home, away := swap.NormalizeTeam(game[schedule.HOMETEAM]), swap.NormalizeTeam(game[schedule.AWAYTEAM])
+			if (home == a && away == b) || (home == b && away == a) {
+				return day
+			}
+		}
+	}
+	return ""
+}
+
+/*
+Return warnings, by potential match game id, for the matches that would have
+the same two teams meet twice within days.
+*/
+func repeatWarnings(s swap.Swap, days int) map[string][]string {
+	warnings := make(map[string][]string)
+	for _, game := range s.Games {
+		if day := meetsWithin(s.Index, s.Home, s.Away, game[schedule.DATE], days, s.GameID); day != "" {
+			warnings[game[schedule.GAMEID]] = append(warnings[game[schedule.GAMEID]],
+				fmt.Sprintf("%s and %s also meet on %s", s.Home, s.Away, day))
+		}
+		day := meetsWithin(s.Index, game[schedule.HOMETEAM], game[schedule.AWAYTEAM], s.Date, days,
+			game[schedule.GAMEID])
+		if day != "" {
+			warnings[game[schedule.GAMEID]] = append(warnings[game[schedule.GAMEID]],
+				fmt.Sprintf("%s and %s also meet on %s", game[schedule.HOMETEAM], game[schedule.AWAYTEAM], day))
+		}
+	}
+	return warnings
+}
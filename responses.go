@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Responses from previous searches
+
+ The report has a Response column for the manager to fill in as candidate
+ teams reply (i.e. Contacted, Declined, Accepted). When the search for the
+ same game is run again the responses in the previous report are saved in the
+ tracker and carried forward, and the teams in declined games are left out of
+ the new search.
+*/
+
+/*
+Read the responses from a previous CSV report, by game id. No responses are
+returned if the report doesn't exist.
+*/
+func readResponses(fileName string) (map[string]string, error) {
+	fi, err := os.Open(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer fi.Close()
+
+	// Reports written before the summary had its own file end with it, and
+	// it has fewer columns
+	reader := csv.NewReader(fi)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	idCol := slices.Index(rows[0], "Game ID")
+	responseCol := slices.Index(rows[0], "Response")
+	if idCol < 0 || responseCol < 0 {
+		return nil, nil
+	}
+
+	responses := make(map[string]string)
+	for _, row := range rows[1:] {
+		if len(row) <= max(idCol, responseCol) {
+			continue
+		}
+		if response := strings.TrimSpace(row[responseCol]); response != "" {
+			responses[row[idCol]] = response
+		}
+	}
+	return responses, nil
+}
+
+/*
+Return the teams in the games that have been declined.
+*/
+func declinedTeams(responses map[string]string, index schedule.Index) []string {
+	var teams []string
+	for _, games := range index {
+		for _, game := range games {
+			if strings.EqualFold(responses[game[schedule.GAMEID]], "declined") {
+				teams = swap.AddUnique(teams, game[schedule.HOMETEAM])
+				teams = swap.AddUnique(teams, game[schedule.AWAYTEAM])
+			}
+		}
+	}
+	slices.Sort(teams)
+	return teams
+}
+
+/*
+Return the teams playing in each game by game id, normalized so responses to
+the game can be credited to the teams.
+*/
+func gameTeams(index schedule.Index) map[string][]string {
+	teams := make(map[string][]string)
+	for _, games := range index {
+		for _, game := range games {
+			teams[game[schedule.GAMEID]] = []string{swap.NormalizeTeam(game[schedule.HOMETEAM]),
+				swap.NormalizeTeam(game[schedule.AWAYTEAM])}
+		}
+	}
+	return teams
+}
+
+/*
+Return the share of contacts each team has replied to, from the responses
+kept in the tracker for all swap games. A match marked Contacted hasn't
+replied, any other response is a reply. Teams that have never been contacted
+aren't included.
+*/
+func responseRates(tracker *tracker_t, index schedule.Index) map[string]float64 {
+	teams := gameTeams(index)
+
+	contacted := make(map[string]int)
+	replied := make(map[string]int)
+	for _, responses := range tracker.Responses {
+		for matchId, response := range responses {
+			for _, team := range teams[matchId] {
+				contacted[team]++
+				if isReply(response) {
+					replied[team]++
+				}
+			}
+		}
+	}
+
+	rates := make(map[string]float64)
+	for team, count := range contacted {
+		rates[team] = float64(replied[team]) / float64(count)
+	}
+	return rates
+}
+
+/*
+Return the average days each team has taken to reply, from the first search
+for the swap game to the search that found the reply. Teams that have never
+replied aren't included.
+*/
+func replyDays(tracker *tracker_t, index schedule.Index) map[string]float64 {
+	teams := gameTeams(index)
+
+	total := make(map[string]float64)
+	count := make(map[string]int)
+	for gameId, replies := range tracker.Replies {
+		searched, ok := tracker.Searches[gameId]
+		if !ok {
+			continue
+		}
+		for matchId, replied := range replies {
+			days := max(0, replied.Sub(searched).Hours()/24)
+			for _, team := range teams[matchId] {
+				total[team] += days
+				count[team]++
+			}
+		}
+	}
+
+	days := make(map[string]float64)
+	for team, n := range count {
+		days[team] = total[team] / float64(n)
+	}
+	return days
+}
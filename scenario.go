@@ -0,0 +1,235 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Scenarios
+
+ A scenario is a fixed schedule and the inputs of a search in a JSON file,
+ so the search can be replayed without the association's schedule. It is
+ used to check a change still finds the same potential matches, and to show
+ a new manager what each filter does, i.e.
+
+   go-scheduler scenario scenarios/*.json
+
+ A scenario file looks like
+
+	{"name": "Exhibition on a match date",
+	 "schedule": "fixtures/january.json",
+	 "today": "2030-01-01",
+	 "game": "G1",
+	 "excludeTeams": ["M"],
+	 "blackouts": {"2030-01-19": "tournament"},
+	 "days": "sat,sun", "after": "09:00", "before": "18:00",
+	 "household": "GCTCOUGARS2",
+	 "association": "gloucester",
+	 "rank": "score",
+	 "expect": ["C3", "C1"]}
+
+ The schedule is a JSON list of games in the TTM feed format, its path is
+ relative to the scenario file, or the games can be listed in the scenario
+ under "games". Only the schedule, today and game are needed. The built in
+ rules of the association are used, not a local rules file, so a scenario
+ gives the same result everywhere.
+
+ The funnel and the potential matches are printed. With expect, the ids are
+ compared in order and the scenario fails if they differ.
+*/
+
+// Structure to hold a scenario file
+type scenario_t struct {
+	Name         string            `json:"name"`
+	Description  string            `json:"description,omitempty"`
+	Schedule     string            `json:"schedule,omitempty"` // fixture file of games
+	Games        []schedule.Record `json:"games,omitempty"`    // games, instead of a fixture file
+	Today        string            `json:"today"`              // date the search is run on
+	Game         string            `json:"game"`               // id of the game to swap
+	ExcludeTeams []string          `json:"excludeTeams,omitempty"`
+	Blackouts    map[string]string `json:"blackouts,omitempty"`
+	ShortStaffed map[string]string `json:"shortStaffed,omitempty"`
+	Days         string            `json:"days,omitempty"`
+	After        string            `json:"after,omitempty"`
+	Before       string            `json:"before,omitempty"`
+	Household    string            `json:"household,omitempty"`
+	Association  string            `json:"association,omitempty"`
+	Rank         string            `json:"rank,omitempty"`
+	Expect       []string          `json:"expect,omitempty"` // potential match ids in order
+}
+
+/*
+Read a scenario file and the games of its schedule.
+*/
+func readScenario(path string) (scenario_t, error) {
+	var scenario scenario_t
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scenario, err
+	}
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return scenario, fmt.Errorf("%s: %w", path, err)
+	}
+	if scenario.Name == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if scenario.Game == "" || scenario.Today == "" {
+		return scenario, fmt.Errorf("%s: a scenario needs the game and today", path)
+	}
+	if scenario.Schedule != "" {
+		fixture := scenario.Schedule
+		if !filepath.IsAbs(fixture) {
+			fixture = filepath.Join(filepath.Dir(path), fixture)
+		}
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			return scenario, err
+		}
+		var games []schedule.Record
+		if err := json.Unmarshal(data, &games); err != nil {
+			return scenario, fmt.Errorf("%s: %w", fixture, err)
+		}
+		scenario.Games = append(scenario.Games, games...)
+	}
+	if len(scenario.Games) == 0 {
+		return scenario, fmt.Errorf("%s: a scenario needs a schedule or games", path)
+	}
+	return scenario, nil
+}
+
+/*
+Run a scenario's search and print the funnel and the potential matches.
+Returns false if the matches aren't the ones expected.
+*/
+func (scenario scenario_t) run(w io.Writer) (bool, error) {
+	today, err := time.Parse(schedule.DATE_FORMAT, scenario.Today)
+	if err != nil {
+		return false, fmt.Errorf("today: %w", err)
+	}
+	rules, err := swap.Preset(cmp.Or(scenario.Association, swap.DEFAULT_PRESET))
+	if err != nil {
+		return false, err
+	}
+	slots, err := swap.ParseSlots(scenario.Days, scenario.After, scenario.Before)
+	if err != nil {
+		return false, err
+	}
+	ranking, err := swap.ParseRanking(scenario.Rank)
+	if err != nil {
+		return false, err
+	}
+	if slices.Contains(ranking, "distance") {
+		return false, errors.New("scenarios have no arena locations to rank by distance")
+	}
+
+	engine := swap.NewEngine(func() ([]schedule.Record, error) { return scenario.Games, nil },
+		swap.WithToday(today), swap.WithRules(rules))
+	s, division, err := engine.Search(scenario.Game)
+	if err != nil {
+		return false, err
+	}
+
+	// The same filters as a search from the command line, in the same order
+	if teams := swap.ParseTeams(strings.Join(scenario.ExcludeTeams, ",")); len(teams) > 0 {
+		s.RemoveTeams(teams, "excluded team")
+		s.AddStep("after excluded teams")
+	}
+	if slots.Limited() {
+		s.RemoveOutsideSlots(slots)
+		s.AddStep("after preferred slots")
+	}
+	if scenario.Household != "" {
+		s.RemoveHouseholdDates(scenario.Household)
+		s.AddStep("after household conflicts")
+	}
+	if len(scenario.Blackouts) > 0 {
+		s.RemoveDates(scenario.Blackouts, "blackout date")
+		s.AddStep("after blackout dates")
+	}
+	scores := s.Scores(swap.DefaultWeights, scenario.ShortStaffed)
+	s.Rank(ranking, swap.RankData{Scores: scores})
+	warnings := s.DeprioritizeDates(scenario.ShortStaffed)
+	for id, conflicts := range s.CheckBothWays() {
+		warnings[id] = append(warnings[id], conflicts...)
+	}
+
+	fmt.Fprintf(w, "%s: %s %s on %s, %s vs %s\n", scenario.Name, division.Name, s.GameID, s.Date,
+		s.Home, s.Away)
+	if scenario.Description != "" {
+		fmt.Fprintln(w, "  "+scenario.Description)
+	}
+	fmt.Fprintln(w, "  "+funnelText(s))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var ids []string
+	for _, game := range s.Games {
+		id := game[schedule.GAMEID]
+		ids = append(ids, id)
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\t%.1f\t%s\n", game[schedule.DIVISION], id, game[schedule.DATE],
+			game[schedule.TIME], game[schedule.VENUE], game[schedule.HOMETEAM], game[schedule.AWAYTEAM], scores[id],
+			strings.Join(warnings[id], "; "))
+	}
+	tw.Flush()
+
+	if scenario.Expect == nil {
+		return true, nil
+	}
+	if !slices.Equal(ids, scenario.Expect) {
+		fmt.Fprintf(w, "  FAIL expected %s, found %s\n", strings.Join(scenario.Expect, ", "), strings.Join(ids, ", "))
+		return false, nil
+	}
+	fmt.Fprintln(w, "  ok")
+	return true, nil
+}
+
+/*
+Run scenario files and report the ones that don't give the expected
+potential matches.
+
+	scenario FILE.json...
+*/
+func scenarioCommand(args []string) error {
+	flags := flag.NewFlagSet("scenario", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go-scheduler scenario FILE.json...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		return errors.New("scenario needs the scenario files to run")
+	}
+
+	var failed []string
+	for _, path := range flags.Args() {
+		scenario, err := readScenario(path)
+		if err != nil {
+			return err
+		}
+		ok, err := scenario.run(os.Stdout)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if !ok {
+			failed = append(failed, scenario.Name)
+		}
+		fmt.Println()
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d scenarios failed: %s", len(failed), flags.NArg(), strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+)
+
+/*
+ Schedule corrections
+
+ The website often lags behind changes everyone already knows about. A
+ corrections file gives the new date, time or arena of games by game id and is
+ applied on top of the downloaded schedule. Corrections the schedule has
+ caught up with are reported so they can be removed from the file.
+*/
+
+// Structure to hold the corrected fields of a game, blank fields are unchanged
+type Correction struct {
+	Date  string `json:"date"`
+	Time  string `json:"time"`
+	Venue string `json:"venue"`
+}
+
+/*
+Read the corrections file, a JSON object of corrections by game id. No
+corrections are returned if the file doesn't exist.
+*/
+func ReadCorrections(path string) (map[string]Correction, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var corrections map[string]Correction
+	if err := json.Unmarshal(data, &corrections); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for id, correction := range corrections {
+		if correction.Date == "" {
+			continue
+		}
+		if _, err := time.Parse(DATE_FORMAT, correction.Date); err != nil {
+			return nil, fmt.Errorf("%s: game %s: date %s is not YYYY-MM-DD", path, id, correction.Date)
+		}
+	}
+	return corrections, nil
+}
+
+/*
+Apply the corrections to the schedule records. Returns a note for each
+correction that the schedule already has or whose game isn't in the
+schedule, sorted by game id.
+*/
+func Correct(records []Record, corrections map[string]Correction) []string {
+	found := make(map[string]bool)
+	var notes []string
+	for i := range records {
+		g := &records[i]
+		correction, ok := corrections[g.GameID]
+		if !ok {
+			continue
+		}
+		found[g.GameID] = true
+
+		changed := false
+		update := func(field *string, value string) {
+			if value != "" && *field != value {
+				*field = value
+				changed = true
+			}
+		}
+		update(&g.GameDate, correction.Date)
+		update(&g.GameTime, correction.Time)
+		update(&g.Venue, correction.Venue)
+		if !changed {
+			notes = append(notes, g.GameID+": the schedule already has the correction")
+		}
+	}
+	for id := range corrections {
+		if !found[id] {
+			notes = append(notes, id+": not in the schedule")
+		}
+	}
+	slices.Sort(notes)
+	return notes
+}
@@ -0,0 +1,85 @@
+package schedule
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/GeoffreyPlitt/debuggo"
+)
+
+/*
+Download Schedule
+
+This is used to download the schedule from the Total Team Management
+website. To get the URL (Note: done with Firefox)
+ 1. Navigate to the TTM website schedules
+ 2. Select 'All Divisions'
+ 3. Enable Developer Tools: Ctrl + Shift + I
+ 4. In Developer Tools, select Network tab
+ 5. Click the TTM Export... button and choose CSV format
+ 6. Close the popup window
+ 7. In Developer Tools right click the new File value
+ 8. Select Copy Value / Copy URL
+*/
+
+// Structure to hold the TTM export options for an organization's schedule,
+// the values are the ones in the export URL copied as above
+type Org struct {
+	ID     string // organization id (orgID)
+	League string // league selected in the export (option1)
+	Season string // season selected in the export (option3)
+}
+
+// Export options of the association's schedule
+var DefaultOrg = Org{ID: ORG_ID, League: "88", Season: "2"}
+
+/*
+Return the URL of the schedule export for the organization, for all
+divisions.
+*/
+func (org Org) URL() string {
+	return "https://api.off-iceoffice.ca/ooAPI/v1/schedules/" +
+		"games/?orgID=" + url.QueryEscape(org.ID) + "&option1=" + url.QueryEscape(org.League) +
+		"&option2=9999&option3=" + url.QueryEscape(org.Season)
+}
+
+/*
+Return the URL of the schedule export for a TTM organization id, with the
+association's league and season.
+*/
+func URL(orgID string) string {
+	org := DefaultOrg
+	org.ID = orgID
+	return org.URL()
+}
+
+/*
+Download the schedule for a TTM organization id. The records are decoded
+straight from the response body.
+*/
+func Download(orgID string) ([]Record, error) {
+	// create a debugger object
+	var debug = debuggo.Debug("schedule.Download")
+
+	scheduleURL := URL(orgID)
+	debug("Downloading schedule from %s", scheduleURL)
+	resp, err := http.Get(scheduleURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", scheduleURL, resp.Status)
+	}
+
+	var records []Record
+	err = Decode(resp.Body, func(record Record) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the schedule: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,155 @@
+/*
+Package schedule downloads and decodes association schedules from Total Team
+Management (TTM) and converts them to rows for searching.
+
+Each game is a row in the same format as the schedule CSV: division, game id,
+date, time, arena, home team and away team, followed by any extra columns.
+The column constants give the position of each field.
+*/
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// TTM organization id of the association's schedule
+const ORG_ID = "1567976101-7023700001"
+
+// Constants used to access gameInfo records in the CSV
+const (
+	DATE_FORMAT = "2006-01-02"
+	DIVISION    = 0
+	GAMEID      = 1
+	DATE        = 2
+	TIME        = 3
+	VENUE       = 4
+	HOMETEAM    = 5
+	AWAYTEAM    = 6
+	GAMESTATUS  = 7
+)
+
+// Structure to hold TTM Schedule Records
+// Used to unmarshal the decoded JSON data
+type Record struct {
+	ID       string `json:"id"`
+	GameID   string `json:"gameID"`
+	GameDate string `json:"gameDate"`
+	GameTime string `json:"gameTime"`
+	Venue    string `json:"venue"`
+	Division string `json:"division"`
+	HomeTeam string `json:"homeTeam"`
+	AwayTeam string `json:"awayTeam"`
+
+	// Any other fields in the record (i.e. status, score, round)
+	Extra map[string]string `json:"-"`
+}
+
+/*
+Decode a schedule record, keeping any fields that aren't known in Extra.
+*/
+func (g *Record) UnmarshalJSON(data []byte) error {
+	// Decode the known fields with the default decoding
+	type record Record
+	if err := json.Unmarshal(data, (*record)(g)); err != nil {
+		return err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for name, value := range fields {
+		switch name {
+		case "id", "gameID", "gameDate", "gameTime", "venue", "division", "homeTeam", "awayTeam":
+			continue
+		}
+		if g.Extra == nil {
+			g.Extra = make(map[string]string)
+		}
+		if value != nil {
+			g.Extra[name] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// Header for the schedule columns, extra columns follow these
+var Header = []string{"Division", "GameID", "Date", "Time", "Arena", "Home Team", "Away Team"}
+
+/*
+Return the names of the extra fields found in any of the records, sorted.
+*/
+func ExtraColumns(records []Record) []string {
+	var names []string
+	for _, g := range records {
+		for name := range g.Extra {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+/*
+Return the schedule record as a row in the same format as the schedule CSV,
+followed by the values of the extra columns.
+*/
+func (g Record) Row(extra []string) []string {
+	row := []string{
+		g.Division,
+		g.GameID,
+		g.GameDate,
+		g.GameTime,
+		g.Venue,
+		g.HomeTeam,
+		g.AwayTeam,
+	}
+	for _, name := range extra {
+		row = append(row, g.Extra[name])
+	}
+	return row
+}
+
+/*
+Convert the schedule records to rows for searching. Every row includes the
+extra columns, in the same order, even if the record doesn't have them.
+*/
+func Rows(records []Record, extra []string) [][]string {
+	rows := make([][]string, 0, len(records))
+	for _, g := range records {
+		rows = append(rows, g.Row(extra))
+	}
+	return rows
+}
+
+// Score added after a team name once the game is played, i.e.
+// BLACKBURN STINGERS U15 B1 (1)
+var scorePattern = regexp.MustCompile(`\(\d+\)\s*$`)
+
+/*
+Check if a game has a score recorded, which means it has been played whatever
+its date says.
+*/
+func Scored(game []string) bool {
+	return scorePattern.MatchString(game[HOMETEAM]) || scorePattern.MatchString(game[AWAYTEAM])
+}
+
+// Games in the schedule bucketed by date
+type Index map[string][][]string
+
+/*
+Bucket the games in the schedule by date, so the games played on a date can be
+found without scanning the whole schedule.
+*/
+func IndexByDate(games [][]string) Index {
+	index := make(Index)
+	for _, game := range games {
+		index[game[DATE]] = append(index[game[DATE]], game)
+	}
+	return index
+}
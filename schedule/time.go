@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // the location is needed even where the system has no zone files
+)
+
+/*
+ Game times
+
+ The schedule gives dates and times in the association's local time without
+ a zone. Outputs that are read elsewhere (calendar files and emails) need the
+ zone, so the times are placed in the association's location.
+*/
+
+// Time zone of the association's games unless another is set
+const TIMEZONE = "America/Toronto"
+
+// Location of the association's games, loaded once
+var location = loadLocation(TIMEZONE)
+
+// Layouts of the game times in the schedule
+var timeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3:04 pm", "3:04pm"}
+
+/*
+Load the location of a time zone known to be in the embedded zone data.
+*/
+func loadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		// Only possible if the embedded zone data is missing the zone
+		return time.Local
+	}
+	return location
+}
+
+/*
+Set the time zone of the association's games, i.e. America/Vancouver, for
+associations outside Toronto's zone. It has to be set before any game times
+are read.
+*/
+func SetTimeZone(name string) error {
+	loaded, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("time zone %s: %w", name, err)
+	}
+	location = loaded
+	return nil
+}
+
+/*
+Return the location of the association's games.
+*/
+func Location() *time.Location {
+	return location
+}
+
+/*
+Return the start of a game in the association's location. The time is
+midnight if the game has no time.
+*/
+func Start(game []string) (time.Time, error) {
+	location := Location()
+	date, err := time.ParseInLocation(DATE_FORMAT, game[DATE], location)
+	if err != nil {
+		return time.Time{}, err
+	}
+	gameTime := strings.TrimSpace(game[TIME])
+	if gameTime == "" {
+		return date, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(DATE_FORMAT+" "+layout, game[DATE]+" "+gameTime, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("game %s: time %s not understood", game[GAMEID], gameTime)
+}
@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+/*
+ Streaming TTM decoding
+
+ TTM responses wrap the data in a JSON object with the records as a base64
+ encoded JSON array: {"id": 1, "data": "W3siaWQiOi..."}. For a full season
+ export, reading the body, decoding the base64 and then unmarshaling the
+ result holds three copies of the data in memory. Instead, the base64 string
+ is read directly from the body and the records are decoded one at a time.
+*/
+
+// Reader for the base64 "data" string of a TTM response. Reads end at the
+// closing quote of the string.
+type ttmDataReader struct {
+	r    *bufio.Reader
+	done bool
+}
+
+/*
+Skip forward in the response body to the start of the "data" string.
+*/
+func newTTMDataReader(body io.Reader) (*ttmDataReader, error) {
+	r := bufio.NewReader(body)
+	key := []byte(`"data"`)
+	matched := 0
+	for matched < len(key) {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("no data in TTM response: %w", err)
+		}
+		switch {
+		case c == key[matched]:
+			matched++
+		case c == key[0]:
+			matched = 1
+		default:
+			matched = 0
+		}
+	}
+
+	// Skip the colon and any white space before the opening quote
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("no data in TTM response: %w", err)
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n', ':':
+			continue
+		case '"':
+			return &ttmDataReader{r: r}, nil
+		default:
+			return nil, fmt.Errorf("TTM response data is not a string")
+		}
+	}
+}
+
+func (d *ttmDataReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && !d.done {
+		c, err := d.r.ReadByte()
+		if err == io.EOF {
+			return n, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return n, err
+		}
+		switch c {
+		case '"':
+			d.done = true
+		case '\\':
+			// Some servers escape the slashes in base64 as \/ and
+			// line breaks can appear as \n
+			c, err = d.r.ReadByte()
+			if err != nil {
+				return n, err
+			}
+			switch c {
+			case '/':
+				p[n] = '/'
+				n++
+			case 'n', 'r':
+			default:
+				return n, fmt.Errorf("unexpected escape \\%c in TTM data", c)
+			}
+		default:
+			p[n] = c
+			n++
+		}
+	}
+	if n == 0 && d.done {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+/*
+Decode the records in a TTM response body one at a time, calling fn with each
+record.
+*/
+func Decode[T any](body io.Reader, fn func(T) error) error {
+	data, err := newTTMDataReader(body)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(base64.NewDecoder(base64.StdEncoding, data))
+
+	// The records are a JSON array
+	if tok, err := decoder.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('[') {
+		return errors.New("TTM data is not a list of records")
+	}
+	for decoder.More() {
+		var record T
+		if err := decoder.Decode(&record); err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	_, err = decoder.Token()
+	return err
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Sending swap request emails
+
+ The swap request emails can be sent straight from the search through the
+ manager's SMTP server (i.e. smtp.gmail.com:587 with an app password), instead
+ of opening each draft. Sending is opt-in: the emails are listed and sent only
+ after the manager confirms, or only listed for a dry run. The emails are
+ spaced out by sendInterval so the server doesn't treat them as spam, and the
+ matches emailed are recorded in the tracker as Contacted.
+
+ The SMTP password is the "smtp-password" credential.
+*/
+
+// Minimum time between emails sent
+var sendInterval = 5 * time.Second
+
+// Structure to hold the SMTP server settings
+type smtp_t struct {
+	server string // host:port
+	user   string // login, no authentication if empty
+	from   string // From address
+}
+
+// SMTP server used for sending, set from the flags
+var mailer smtp_t
+
+/*
+Send a message to its To and Cc addresses.
+*/
+func (mailer smtp_t) send(message message_t) error {
+	if mailer.server == "" || mailer.from == "" {
+		return errors.New("sending email needs -smtp-server and -email-from")
+	}
+	host, _, err := net.SplitHostPort(mailer.server)
+	if err != nil {
+		return fmt.Errorf("smtp server %s: %w", mailer.server, err)
+	}
+
+	var auth smtp.Auth
+	if mailer.user != "" {
+		password, err := credential("smtp-password")
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", mailer.user, password, host)
+	}
+	recipients := append(append([]string{}, message.To...), message.Cc...)
+	return smtp.SendMail(mailer.server, auth, mailer.from, recipients, message.eml(mailer.from, false))
+}
+
+/*
+Send the swap request email for each potential match with an email, after
+listing them and asking for confirmation. Nothing is sent for a dry run.
+Returns the ids of the matches emailed.
+*/
+func sendSwapRequests(report report_t, templateFile string, dryRun bool) ([]string, error) {
+	tmpl, err := readEmailTemplate(templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []message_t
+	var ids []string
+	for _, match := range report.swap.Games {
+		message, err := swapRequest(tmpl, report, match)
+		if err != nil {
+			return nil, err
+		}
+		if len(message.To) == 0 {
+			fmt.Printf("No email for %s or %s, not emailing about %s\n", match[schedule.HOMETEAM],
+				match[schedule.AWAYTEAM], match[schedule.GAMEID])
+			continue
+		}
+		fmt.Printf("  %s: %s\n", match[schedule.GAMEID], strings.Join(message.To, ", "))
+		messages = append(messages, message)
+		ids = append(ids, match[schedule.GAMEID])
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	if dryRun {
+		fmt.Printf("Dry run, %d swap request emails not sent\n", len(messages))
+		return nil, nil
+	}
+
+	fmt.Printf("Send %d swap request emails from %s? [y/N]: ", len(messages), mailer.from)
+	if answer := strings.ToLower(readLine()); answer != "y" && answer != "yes" {
+		fmt.Println("Emails not sent")
+		return nil, nil
+	}
+	for i, message := range messages {
+		if i > 0 {
+			if err := sleep(sendInterval); err != nil {
+				return ids[:i], err
+			}
+		}
+		if err := mailer.send(message); err != nil {
+			return ids[:i], fmt.Errorf("sending to %s: %w", strings.Join(message.To, ", "), err)
+		}
+		fmt.Printf("Sent swap request for %s\n", ids[i])
+	}
+	return ids, nil
+}
+
+// Notifier sending the event by email
+type emailNotifier struct {
+	to []string
+}
+
+func (notifier emailNotifier) Notify(event event_t) error {
+	for _, to := range notifier.to {
+		if !contacts.ValidEmail(to) {
+			return fmt.Errorf("invalid email %s", to)
+		}
+	}
+	subject, _, _ := strings.Cut(event.Text, "\n")
+	return mailer.send(message_t{To: notifier.to, Subject: "go-scheduler: " + subject, Body: event.Text + "\n"})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+/*
+ Interrupting a run
+
+ A run can be stopped with Ctrl-C (or killed by a scheduler with SIGTERM)
+ while it downloads or writes files. Downloads and the waits between requests
+ and emails are cancelled straight away, but a file or tracker change being
+ written is finished first so nothing is left half written or locked. A
+ second interrupt exits without waiting.
+*/
+
+// Exit code after an interrupt, the shell's code for SIGINT
+const EXIT_INTERRUPTED = 130
+
+var (
+	// Cancelled when the run is interrupted
+	runContext, cancelRun = context.WithCancel(context.Background())
+
+	// Number of writes in progress, the interrupt waits for none
+	writes     int
+	writesMu   sync.Mutex
+	writesDone = sync.NewCond(&writesMu)
+)
+
+/*
+Stop the run cleanly on the signals.
+*/
+func handleSignals(signals ...os.Signal) {
+	caught := make(chan os.Signal, 2)
+	signal.Notify(caught, signals...)
+	go func() {
+		sig := <-caught
+		fmt.Fprintf(os.Stderr, "\n%v: stopping after the files being written\n", sig)
+		cancelRun()
+
+		written := make(chan struct{})
+		go func() {
+			// the lock is kept so no other write starts
+			writesMu.Lock()
+			for writes > 0 {
+				writesDone.Wait()
+			}
+			close(written)
+		}()
+		select {
+		case <-written:
+		case <-caught:
+			fmt.Fprintln(os.Stderr, "Stopped without waiting")
+		}
+		os.Exit(EXIT_INTERRUPTED)
+	}()
+}
+
+/*
+Run a write so an interrupt waits for it to finish. Writes can be nested.
+*/
+func whileWriting(write func() error) error {
+	writesMu.Lock()
+	writes++
+	writesMu.Unlock()
+	defer func() {
+		writesMu.Lock()
+		writes--
+		if writes == 0 {
+			writesDone.Broadcast()
+		}
+		writesMu.Unlock()
+	}()
+	return write()
+}
+
+/*
+Wait for the duration. Returns an error if the run is interrupted first.
+*/
+func sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-runContext.Done():
+		return runContext.Err()
+	}
+}
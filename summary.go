@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Report summary
+
+ Gives an overview of the potential matches so a manager can see at a glance
+ where the options are and whether the search was too restrictive.
+
+ The summary is printed after the search, and is written into the report
+ except for CSV, where it goes to <gameId>-summary.csv so the report is only
+ the header and a row for each match, and any spreadsheet can read it.
+*/
+
+/*
+Return the Saturday starting the week of the date, so games are grouped by
+weekend.
+*/
+func weekendOf(date string) string {
+	d, err := time.Parse(schedule.DATE_FORMAT, date)
+	if err != nil {
+		return date
+	}
+	offset := (int(d.Weekday()) - int(time.Saturday) + 7) % 7
+	return d.AddDate(0, 0, -offset).Format(schedule.DATE_FORMAT)
+}
+
+/*
+Count the potential matches by the value returned for each game.
+*/
+func countBy(games [][]string, key func(game []string) string) map[string]int {
+	counts := make(map[string]int)
+	for _, game := range games {
+		counts[key(game)]++
+	}
+	return counts
+}
+
+/*
+Return the counts as rows of the value and its count, sorted by value.
+*/
+func countRows(counts map[string]int) [][]string {
+	var rows [][]string
+	for _, k := range slices.Sorted(maps.Keys(counts)) {
+		rows = append(rows, []string{k, fmt.Sprint(counts[k])})
+	}
+	return rows
+}
+
+/*
+Return the number of teams, dates, arenas or games excluded and the matches
+they removed for each reason, in the order the filters ran.
+*/
+func excludedCounts(excluded []swap.Exclusion) [][]string {
+	var reasons []string
+	items := make(map[string]int)
+	games := make(map[string]int)
+	for _, e := range excluded {
+		if _, ok := items[e.Reason]; !ok {
+			reasons = append(reasons, e.Reason)
+		}
+		items[e.Reason]++
+		games[e.Reason] += e.Games
+	}
+	var rows [][]string
+	for _, reason := range reasons {
+		rows = append(rows, []string{reason, fmt.Sprint(items[reason]), fmt.Sprint(games[reason])})
+	}
+	return rows
+}
+
+/*
+Return the summary of the report as rows of labels and counts, with a blank
+row between each section.
+*/
+func (report report_t) summaryRows() [][]string {
+	s := report.swap
+
+	rows := [][]string{
+		{"Summary"},
+		{"Potential matches", fmt.Sprint(len(s.Games))},
+	}
+	if len(s.Excluded) > 0 {
+		rows = append(rows, []string{}, []string{"Excluded because", "Excluded", "Matches"})
+		rows = append(rows, excludedCounts(s.Excluded)...)
+	}
+	rows = append(rows, []string{}, []string{"Division", "Matches"})
+	rows = append(rows, countRows(countBy(s.Games, func(g []string) string { return g[schedule.DIVISION] }))...)
+	rows = append(rows, []string{}, []string{"Weekend", "Matches"})
+	rows = append(rows, countRows(countBy(s.Games, func(g []string) string { return weekendOf(g[schedule.DATE]) }))...)
+	if len(report.tiers) > 0 {
+		rows = append(rows, []string{}, []string{"Tier", "Matches"})
+		for _, tier := range []string{swap.TIER_STRONG, swap.TIER_POSSIBLE, swap.TIER_LONGSHOT} {
+			n := 0
+			for _, g := range s.Games {
+				if report.tiers[g[schedule.GAMEID]] == tier {
+					n++
+				}
+			}
+			rows = append(rows, []string{tier, fmt.Sprint(n)})
+		}
+	}
+	rows = append(rows, []string{}, []string{"Arena", "Matches"})
+	rows = append(rows, countRows(countBy(s.Games, func(g []string) string { return g[schedule.VENUE] }))...)
+	// The contact problems can include emails so they aren't shared
+	if problems := contacts.Problems(report.contacts, s.Games); len(problems) > 0 && !report.redact {
+		rows = append(rows, []string{}, []string{"Team", "Contact problem"})
+		rows = append(rows, problems...)
+	}
+	return rows
+}
+
+/*
+Print the summary of the report.
+*/
+func printSummary(report report_t) {
+	for _, row := range report.summaryRows() {
+		switch len(row) {
+		case 0:
+			fmt.Println()
+		case 1:
+			fmt.Println(row[0])
+		default:
+			fmt.Printf("  %-40s %s\n", row[0], strings.Join(row[1:], " "))
+		}
+	}
+}
+
+/*
+Write the summary of the report to a CSV file of its own, so the report
+itself is only the header and a row for each potential match.
+*/
+func writeSummaryCSV(report report_t, fileName string) error {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.WriteAll(report.summaryRows())
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return writeFileAtomic(fileName, buf.Bytes(), 0644)
+}
+
+/*
+Print how many potential matches were left after each filter, so it's clear
+which one removed the most.
+*/
+func printFunnel(s swap.Swap) {
+	fmt.Println(funnelText(s))
+}
+
+/*
+Return the funnel on one line, i.e. 120 games -> 80 after cutoff -> ...
+*/
+func funnelText(s swap.Swap) string {
+	var steps []string
+	for _, step := range s.Funnel {
+		steps = append(steps, fmt.Sprintf("%d %s", step.Games, step.Label))
+	}
+	return strings.Join(steps, " -> ")
+}
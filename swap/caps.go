@@ -0,0 +1,34 @@
+package swap
+
+import "github.com/leonard0022/go-scheduler/schedule"
+
+/*
+ Candidate caps
+
+ A long list of games against the same opponent isn't useful, so the number
+ of potential matches from each division, and with each team, can be capped.
+ Matches are kept in the order they are listed, so ranked matches keep the
+ best ones.
+*/
+
+/*
+Keep at most perDivision potential matches from each division and perTeam
+with each team. A cap of 0 is no limit.
+*/
+func (swap *Swap) CapCandidates(perDivision, perTeam int) {
+	divisions := make(map[string]int)
+	teams := make(map[string]int)
+	swap.removeBy("over the caps", func(game []string) string {
+		home, away := NormalizeTeam(game[schedule.HOMETEAM]), NormalizeTeam(game[schedule.AWAYTEAM])
+		if perDivision > 0 && divisions[game[schedule.DIVISION]] >= perDivision {
+			return game[schedule.GAMEID]
+		}
+		if perTeam > 0 && (teams[home] >= perTeam || teams[away] >= perTeam) {
+			return game[schedule.GAMEID]
+		}
+		divisions[game[schedule.DIVISION]]++
+		teams[home]++
+		teams[away]++
+		return ""
+	})
+}
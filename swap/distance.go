@@ -0,0 +1,47 @@
+package swap
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Arena distance
+
+ Swapping moves the team to the potential match's ice, so a match at a far
+ away arena can cost more in travel than the swap is worth. Given the
+ distance to each arena, far matches can be left out or listed last. Arenas
+ with no known distance are kept, and listed after the ones with a distance.
+*/
+
+/*
+Remove the potential matches at arenas farther than maxKm.
+*/
+func (swap *Swap) RemoveFarVenues(distances map[string]float64, maxKm float64) {
+	swap.removeBy(fmt.Sprintf("farther than %g km", maxKm), func(game []string) string {
+		if km, ok := distances[game[schedule.VENUE]]; ok && km > maxKm {
+			return game[schedule.VENUE]
+		}
+		return ""
+	})
+}
+
+/*
+Order the potential matches nearest arena first, keeping the date order for
+games at the same distance.
+*/
+func (swap *Swap) RankByDistance(distances map[string]float64) {
+	distance := func(game []string) float64 {
+		if km, ok := distances[game[schedule.VENUE]]; ok {
+			return km
+		}
+		return math.Inf(1)
+	}
+	slices.SortStableFunc(swap.Games, func(a, b []string) int {
+		return cmp.Compare(distance(a), distance(b))
+	})
+}
@@ -0,0 +1,141 @@
+package swap
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Search engine
+
+ Runs a complete search for other tools: loads the schedule, sets up the swap
+ for a game and finds its potential matches. It is configured with options,
+ i.e.
+
+   engine := swap.NewEngine(swap.OrgSource(orgID), swap.WithCutoff(10*24*time.Hour))
+   s, division, err := engine.Search("HLU1501")
+
+ The schedule source is required so the engine never guesses which
+ association to download.
+*/
+
+// Structure to hold the search settings
+type Engine struct {
+	cutoff time.Duration // games sooner than this are left out
+	today  time.Time     // day the cutoff is from, now if zero
+	rules  Rules         // division rules
+	source Source        // loads the schedule
+	logger *log.Logger   // progress messages
+}
+
+// Function that loads the schedule
+type Source func() ([]schedule.Record, error)
+
+// Function that changes a search setting
+type Option func(*Engine)
+
+/*
+Create a search engine that loads the schedule from the source. Without
+options it uses the division rules in Divisions and leaves out games in the
+next 10 days.
+*/
+func NewEngine(source Source, options ...Option) *Engine {
+	engine := &Engine{
+		cutoff: 10 * 24 * time.Hour,
+		rules:  Divisions,
+		source: source,
+		logger: log.New(io.Discard, "", 0),
+	}
+	for _, option := range options {
+		option(engine)
+	}
+	return engine
+}
+
+/*
+Leave out games sooner than the cutoff from now, and refuse to search for
+games that are.
+*/
+func WithCutoff(cutoff time.Duration) Option {
+	return func(engine *Engine) { engine.cutoff = cutoff }
+}
+
+/*
+Search as if it were the given day, so the same schedule always gives the
+same potential matches.
+*/
+func WithToday(today time.Time) Option {
+	return func(engine *Engine) { engine.today = today }
+}
+
+/*
+Use other division rules.
+*/
+func WithRules(rules Rules) Option {
+	return func(engine *Engine) { engine.rules = rules }
+}
+
+/*
+Download the schedule of an organization from TTM, i.e. schedule.ORG_ID.
+*/
+func OrgSource(orgID string) Source {
+	return func() ([]schedule.Record, error) { return schedule.Download(orgID) }
+}
+
+/*
+Write progress messages to the logger.
+*/
+func WithLogger(logger *log.Logger) Option {
+	return func(engine *Engine) { engine.logger = logger }
+}
+
+/*
+Search the schedule for the potential matches for a game.
+*/
+func (engine *Engine) Search(gameId string) (Swap, Division, error) {
+	if engine.source == nil {
+		return Swap{}, Division{}, fmt.Errorf("no schedule source to search")
+	}
+	records, err := engine.source()
+	if err != nil {
+		return Swap{}, Division{}, err
+	}
+	engine.logger.Printf("Loaded %d games", len(records))
+	games := schedule.Rows(records, schedule.ExtraColumns(records))
+
+	swap, err := newSwap(games, schedule.IndexByDate(games), gameId)
+	if err != nil {
+		return Swap{}, Division{}, err
+	}
+	division, err := engine.rules.Find(swap.Game[schedule.DIVISION])
+	if err != nil {
+		return swap, Division{}, fmt.Errorf("game %s: %w", gameId, err)
+	}
+	engine.logger.Printf("Game %s is in %s, searching %s", gameId, division.Name, division.Swaps)
+
+	today := engine.today
+	if today.IsZero() {
+		today = time.Now()
+	}
+	cutOffDate := today.Add(engine.cutoff)
+	gameDate, err := time.Parse(schedule.DATE_FORMAT, swap.Date)
+	if err != nil {
+		return swap, division, err
+	}
+	if gameDate.Before(cutOffDate) {
+		return swap, division, fmt.Errorf("game date is before cut off date of %s",
+			cutOffDate.Format(schedule.DATE_FORMAT))
+	}
+
+	swappableRe, err := CompileCached(division.SwapsRegex)
+	if err != nil {
+		return swap, division, err
+	}
+	swap.FindCandidates(swappableRe, cutOffDate)
+	engine.logger.Printf("Found %d potential matches", len(swap.Games))
+	return swap, division, nil
+}
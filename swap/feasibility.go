@@ -0,0 +1,82 @@
+package swap
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Two-way check
+
+ A swap moves our game to the potential match's date and the potential match
+ to ours, so all four teams have to be free on their new date. The search
+ drops the dates our teams play on and the teams playing on our date, but it
+ only looks at the games left after the division filter and compares our
+ team names as written, so a game our team plays in another division or
+ under a name with a score attached can slip through in one direction.
+
+ The check goes through the whole schedule both ways, with the team names
+ normalized. Potential matches with a conflict are listed last with a
+ warning saying which side isn't free, as the schedule may be out of date.
+*/
+
+/*
+Return the teams, out of the given ones, playing a game other than gameId
+on a date. Games with a score have already been played and don't count.
+*/
+func (swap *Swap) playingOn(date, gameId string, teams ...string) []string {
+	var playing []string
+	for _, game := range swap.Index[date] {
+		if game[schedule.GAMEID] == gameId || schedule.Scored(game) {
+			continue
+		}
+		for _, team := range teams {
+			name := NormalizeTeam(team)
+			if NormalizeTeam(game[schedule.HOMETEAM]) == name || NormalizeTeam(game[schedule.AWAYTEAM]) == name {
+				playing = AddUnique(playing, name)
+			}
+		}
+	}
+	return playing
+}
+
+/*
+Return the conflicts of a potential match in both directions: our teams
+playing on the match's date and the match's teams playing on our date.
+*/
+func (swap *Swap) conflicts(game []string) []string {
+	var conflicts []string
+	for _, team := range swap.playingOn(game[schedule.DATE], game[schedule.GAMEID], swap.Home, swap.Away) {
+		conflicts = append(conflicts, fmt.Sprintf("%s already plays on %s, the date of this match",
+			team, game[schedule.DATE]))
+	}
+	for _, team := range swap.playingOn(swap.Date, swap.GameID, game[schedule.HOMETEAM], game[schedule.AWAYTEAM]) {
+		conflicts = append(conflicts, fmt.Sprintf("%s already plays on %s, the date of game %s",
+			team, swap.Date, swap.GameID))
+	}
+	return conflicts
+}
+
+/*
+Check both teams of both games are free on their new dates. The potential
+matches with a conflict are moved to the end of the list, keeping the order
+otherwise, and warnings for them are returned by game id.
+*/
+func (swap *Swap) CheckBothWays() map[string][]string {
+	warnings := make(map[string][]string)
+	for _, game := range swap.Games {
+		if conflicts := swap.conflicts(game); len(conflicts) > 0 {
+			warnings[game[schedule.GAMEID]] = []string{"conflict: " + strings.Join(conflicts, "; ")}
+		}
+	}
+	slices.SortStableFunc(swap.Games, func(a, b []string) int {
+		_, conflictA := warnings[a[schedule.GAMEID]]
+		_, conflictB := warnings[b[schedule.GAMEID]]
+		return cmp.Compare(boolRank(conflictA), boolRank(conflictB))
+	})
+	return warnings
+}
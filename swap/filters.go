@@ -0,0 +1,141 @@
+package swap
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/leonard0022/go-scheduler/contacts"
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Candidate filters
+
+ Filters applied to the potential matches after the search, using what is
+ known about the teams beyond the schedule. Each filter records what it left
+ out (a team, date, arena or game) and why, so a search that is too
+ restrictive shows which filter to relax.
+*/
+
+// Structure to hold something left out of the potential matches
+type Exclusion struct {
+	What   string // team, date, arena or game id
+	Reason string // i.e. "declined"
+	Games  int    // potential matches it removed
+}
+
+/*
+Remove the potential matches for which excluded returns what excludes them,
+recording it with the reason. An empty string keeps the game.
+*/
+func (swap *Swap) removeBy(reason string, excluded func(game []string) string) {
+	swap.Games = slices.DeleteFunc(swap.Games, func(game []string) bool {
+		what := excluded(game)
+		if what == "" {
+			return false
+		}
+		idx := slices.IndexFunc(swap.Excluded, func(e Exclusion) bool {
+			return e.What == what && e.Reason == reason
+		})
+		if idx < 0 {
+			swap.Excluded = append(swap.Excluded, Exclusion{What: what, Reason: reason})
+			idx = len(swap.Excluded) - 1
+		}
+		swap.Excluded[idx].Games++
+		return true
+	})
+}
+
+/*
+Remove the potential matches involving any of the teams.
+*/
+func (swap *Swap) RemoveTeams(teams []string, reason string) {
+	swap.removeBy(reason, func(game []string) string {
+		for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+			if team = NormalizeTeam(team); slices.Contains(teams, team) {
+				return team
+			}
+		}
+		return ""
+	})
+}
+
+/*
+Remove the potential matches with any of the game ids.
+*/
+func (swap *Swap) RemoveGames(ids []string, reason string) {
+	swap.removeBy(reason, func(game []string) string {
+		if slices.Contains(ids, game[schedule.GAMEID]) {
+			return game[schedule.GAMEID]
+		}
+		return ""
+	})
+}
+
+/*
+Remove the potential matches on any of the dates. The dates map to why they
+are excluded, which is recorded with the date when it is given.
+*/
+func (swap *Swap) RemoveDates(dates map[string]string, reason string) {
+	swap.removeBy(reason, func(game []string) string {
+		why, found := dates[game[schedule.DATE]]
+		switch {
+		case !found:
+			return ""
+		case why != "":
+			return game[schedule.DATE] + " (" + why + ")"
+		}
+		return game[schedule.DATE]
+	})
+}
+
+/*
+Remove the potential matches with a team that can't be reached by email.
+*/
+func (swap *Swap) RemoveUncontactable(teamContacts map[string]contacts.Contact) {
+	swap.removeBy("no usable contact", func(game []string) string {
+		for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+			if _, reachable := contacts.Problem(teamContacts, team); !reachable {
+				return team
+			}
+		}
+		return ""
+	})
+}
+
+/*
+Order the potential matches so the games with the teams most likely to reply
+come first, and of those the teams quickest to reply. Teams without a history
+count as replying half the time and as slower than any team that has replied,
+games with equal rates and times stay in date order.
+*/
+func (swap *Swap) RankByResponses(rates, replyDays map[string]float64) {
+	rate := func(game []string) float64 {
+		total := 0.0
+		for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+			if r, ok := rates[NormalizeTeam(team)]; ok {
+				total += r
+			} else {
+				total += 0.5
+			}
+		}
+		return total / 2
+	}
+	days := func(game []string) float64 {
+		total, known := 0.0, 0
+		for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+			if d, ok := replyDays[NormalizeTeam(team)]; ok {
+				total += d
+				known++
+			}
+		}
+		if known == 0 {
+			return math.Inf(1)
+		}
+		return total / float64(known)
+	}
+	slices.SortStableFunc(swap.Games, func(a, b []string) int {
+		return cmp.Or(cmp.Compare(rate(b), rate(a)), cmp.Compare(days(a), days(b)))
+	})
+}
@@ -0,0 +1,47 @@
+package swap
+
+import (
+	"slices"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Household conflicts
+
+ Managers often have children on other teams too. The dates the other teams
+ in the household play are left out of the potential matches, so the same
+ family isn't booked at two games on one day.
+*/
+
+/*
+Return the dates any of the teams play on, sorted.
+*/
+func teamDates(index schedule.Index, teams []string) []string {
+	var dates []string
+	for date, games := range index {
+		for _, game := range games {
+			if slices.Contains(teams, NormalizeTeam(game[schedule.HOMETEAM])) ||
+				slices.Contains(teams, NormalizeTeam(game[schedule.AWAYTEAM])) {
+				dates = append(dates, date)
+				break
+			}
+		}
+	}
+	slices.Sort(dates)
+	return dates
+}
+
+/*
+Remove the potential matches on any of the household teams' game dates.
+teams is a comma separated list of team names.
+*/
+func (swap *Swap) RemoveHouseholdDates(teams string) {
+	dates := teamDates(swap.Index, ParseTeams(teams))
+	swap.removeBy("household team playing", func(game []string) string {
+		if _, found := slices.BinarySearch(dates, game[schedule.DATE]); found {
+			return game[schedule.DATE]
+		}
+		return ""
+	})
+}
@@ -0,0 +1,69 @@
+package swap
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+/*
+ Division rule presets
+
+ Rules for known associations are built in, so a new user only has to pick
+ their association's preset instead of writing a rules file. Each preset is
+ a rules file in presets/, named after the association, embedded in the
+ program:
+
+   gloucester  Gloucester Hockey Association, the default
+
+ A preset is only added once the association has given its swap rules, so
+ nobody searches with a guessed swap matrix. Until then a rules file
+ replaces the preset, and a new preset is added by dropping the
+ association's file into presets/.
+*/
+
+// Preset used when no association is chosen
+const DEFAULT_PRESET = "gloucester"
+
+//go:embed presets/*.json
+var presetFiles embed.FS
+
+/*
+Return the names of the built in presets.
+*/
+func Presets() []string {
+	entries, _ := presetFiles.ReadDir("presets")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	slices.Sort(names)
+	return names
+}
+
+/*
+Return the rules of a built in preset, or an error listing the presets if
+there isn't one by that name.
+*/
+func Preset(name string) (Rules, error) {
+	file := path.Join("presets", strings.ToLower(name)+".json")
+	data, err := presetFiles.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("no division rules for association %s (available: %s)",
+			name, strings.Join(Presets(), ", "))
+	}
+	return parseRules(data, file)
+}
+
+/*
+Return the rules of a built in preset, for presets that are known to exist.
+*/
+func mustPreset(name string) Rules {
+	rules, err := Preset(name)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
@@ -0,0 +1,123 @@
+package swap
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Ranking strategies
+
+ The same potential matches can be listed in a different order depending on
+ who is reading them. A manager may want the nearest arenas first, a
+ scheduler the earliest dates. The built in rankers are:
+
+   chronological  earliest game first
+   score          highest score first, see the score weights
+   distance       nearest arena first
+   responsive     teams that reply most often first, then the fastest
+
+ Rankers are given as a comma separated list, the first one decides the order
+ and the ones after it break ties, i.e. distance,score.
+*/
+
+// Ranking used when none is chosen
+const DEFAULT_RANKING = "score"
+
+// Structure to hold what the rankers order the potential matches by
+type RankData struct {
+	Scores    map[string]float64 // score by game id
+	Distances map[string]float64 // distance in km by arena name
+	Rates     map[string]float64 // response rate by normalized team name
+	ReplyDays map[string]float64 // average days to reply by normalized team name
+}
+
+// Interface for ordering the potential matches
+type Ranker interface {
+	Rank(swap *Swap, data RankData)
+}
+
+// Rankers by name
+var Rankers = map[string]Ranker{
+	"chronological": chronologicalRanker{},
+	"score":         scoreRanker{},
+	"distance":      distanceRanker{},
+	"responsive":    responsiveRanker{},
+}
+
+type chronologicalRanker struct{}
+
+/*
+Order the potential matches earliest first, games that can't be placed last.
+*/
+func (chronologicalRanker) Rank(swap *Swap, data RankData) {
+	slices.SortStableFunc(swap.Games, func(a, b []string) int {
+		startA, errA := schedule.Start(a)
+		startB, errB := schedule.Start(b)
+		if errA != nil || errB != nil {
+			return cmp.Compare(boolRank(errA != nil), boolRank(errB != nil))
+		}
+		return startA.Compare(startB)
+	})
+}
+
+type scoreRanker struct{}
+
+func (scoreRanker) Rank(swap *Swap, data RankData) {
+	swap.RankByScore(data.Scores)
+}
+
+type distanceRanker struct{}
+
+func (distanceRanker) Rank(swap *Swap, data RankData) {
+	swap.RankByDistance(data.Distances)
+}
+
+type responsiveRanker struct{}
+
+func (responsiveRanker) Rank(swap *Swap, data RankData) {
+	swap.RankByResponses(data.Rates, data.ReplyDays)
+}
+
+/*
+Parse a comma separated list of ranker names.
+*/
+func ParseRanking(list string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := Rankers[name]; !ok {
+			available := make([]string, 0, len(Rankers))
+			for name := range Rankers {
+				available = append(available, name)
+			}
+			slices.Sort(available)
+			return nil, fmt.Errorf("unknown ranking %s (available: %s)", name, strings.Join(available, ", "))
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		names = []string{DEFAULT_RANKING}
+	}
+	return names, nil
+}
+
+/*
+Order the potential matches by the named rankers, the first one deciding the
+order. The rankers are applied last to first, each keeping the order of the
+one before it for ties.
+*/
+func (swap *Swap) Rank(names []string, data RankData) {
+	for _, name := range slices.Backward(names) {
+		Rankers[name].Rank(swap, data)
+	}
+}
@@ -0,0 +1,63 @@
+package swap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+)
+
+/*
+ Division rules file
+
+ Associations with other age groups or swap policies can replace the built in
+ division table with a JSON file, a list of divisions in the same order and
+ with the same fields as the table:
+
+	[{"name": "U11 A", "nameRegex": "U11.*A",
+	  "swaps": "U11 A -> U11 A-C", "swapsRegex": "U11.*[A-C]"}]
+
+ The first division whose nameRegex matches a game's division is used.
+*/
+
+/*
+Read division rules from a JSON file and check the regular expressions
+compile. No rules are returned if the file doesn't exist.
+*/
+func ReadRules(path string) (Rules, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRules(data, path)
+}
+
+/*
+Parse division rules from JSON and check the regular expressions compile.
+source names where the rules came from in errors.
+*/
+func parseRules(data []byte, source string) (Rules, error) {
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%s: no divisions", source)
+	}
+	for _, division := range rules {
+		for _, expr := range []string{division.NameRegex, division.SwapsRegex} {
+			if expr == "" {
+				return nil, fmt.Errorf("%s: division %q needs a nameRegex and swapsRegex", source, division.Name)
+			}
+			if _, err := regexp.Compile(expr); err != nil {
+				return nil, fmt.Errorf("%s: division %q: %w", source, division.Name, err)
+			}
+		}
+	}
+	return rules, nil
+}
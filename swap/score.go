@@ -0,0 +1,124 @@
+package swap
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Candidate scores
+
+ Some swaps are easier to agree to than others. A potential match on the same
+ day of the week, at a similar time, at the same arena and close to the
+ original date changes the least for both teams, so it is scored higher and
+ listed first. Each part of the score is between 0 and 1 and is multiplied
+ by its weight. A match on a date the team is short-staffed loses the
+ shortStaffed weight, so its score and tier show it is the last resort. The
+ weights can be changed in a JSON file, i.e.
+
+	{"sameDay": 3, "timeSlot": 2, "sameVenue": 1, "closeDate": 2, "shortStaffed": 4}
+
+ A weight of 0 leaves that part out.
+*/
+
+// Hours apart at which two games no longer count as a similar time
+const TIME_SLOT_HOURS = 3
+
+// Days apart at which a game no longer counts as close to the original date
+const CLOSE_DATE_DAYS = 60
+
+// Structure to hold the weight of each part of the score
+type Weights struct {
+	SameDay      float64 `json:"sameDay"`      // same day of the week
+	TimeSlot     float64 `json:"timeSlot"`     // similar start time
+	SameVenue    float64 `json:"sameVenue"`    // same arena
+	CloseDate    float64 `json:"closeDate"`    // close to the original date
+	ShortStaffed float64 `json:"shortStaffed"` // penalty on a short-staffed date
+}
+
+// Weights used when there is no weights file
+var DefaultWeights = Weights{SameDay: 3, TimeSlot: 2, SameVenue: 1, CloseDate: 2, ShortStaffed: 4}
+
+/*
+Read the score weights from a JSON file. Weights missing from the file keep
+their default, and the defaults are returned if the file doesn't exist.
+*/
+func ReadWeights(path string) (Weights, error) {
+	weights := DefaultWeights
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return weights, nil
+	}
+	if err != nil {
+		return weights, err
+	}
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return weights, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, w := range []float64{weights.SameDay, weights.TimeSlot, weights.SameVenue, weights.CloseDate, weights.ShortStaffed} {
+		if w < 0 {
+			return weights, fmt.Errorf("%s: weights can't be negative", path)
+		}
+	}
+	return weights, nil
+}
+
+/*
+Return the score of a potential match for the swap game.
+*/
+func (weights Weights) score(swapGame, game []string) float64 {
+	score := 0.0
+	if strings.EqualFold(strings.TrimSpace(swapGame[schedule.VENUE]), strings.TrimSpace(game[schedule.VENUE])) {
+		score += weights.SameVenue
+	}
+
+	from, errFrom := schedule.Start(swapGame)
+	to, errTo := schedule.Start(game)
+	if errFrom != nil || errTo != nil {
+		return score
+	}
+	if from.Weekday() == to.Weekday() {
+		score += weights.SameDay
+	}
+	minutes := func(h, m int) float64 { return float64(h*60 + m) }
+	apart := math.Abs(minutes(from.Hour(), from.Minute()) - minutes(to.Hour(), to.Minute()))
+	score += weights.TimeSlot * max(0, 1-apart/(TIME_SLOT_HOURS*60))
+	days := math.Abs(to.Sub(from).Hours() / 24)
+	score += weights.CloseDate * max(0, 1-days/CLOSE_DATE_DAYS)
+	return score
+}
+
+/*
+Return the score of each potential match by game id. Matches on the
+short-staffed dates lose the short-staffed weight, down to no less than 0.
+*/
+func (swap *Swap) Scores(weights Weights, shortStaffed map[string]string) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, game := range swap.Games {
+		score := weights.score(swap.Game, game)
+		if _, short := shortStaffed[game[schedule.DATE]]; short {
+			score = max(0, score-weights.ShortStaffed)
+		}
+		scores[game[schedule.GAMEID]] = score
+	}
+	return scores
+}
+
+/*
+Order the potential matches highest score first, keeping the date order for
+equal scores.
+*/
+func (swap *Swap) RankByScore(scores map[string]float64) {
+	slices.SortStableFunc(swap.Games, func(a, b []string) int {
+		return cmp.Compare(scores[b[schedule.GAMEID]], scores[a[schedule.GAMEID]])
+	})
+}
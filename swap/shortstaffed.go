@@ -0,0 +1,49 @@
+package swap
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Short-staffed dates
+
+ Dates the team is missing a goalie or key players aren't ruled out like the
+ dates the team is playing, but the potential matches on them are listed
+ last with a warning and lose the short-staffed weight from their score.
+*/
+
+/*
+Move the potential matches on short-staffed dates to the end of the list,
+keeping the order otherwise, and return warnings for them by game id.
+*/
+func (swap *Swap) DeprioritizeDates(shortStaffed map[string]string) map[string][]string {
+	warnings := make(map[string][]string)
+	for _, game := range swap.Games {
+		if reason, ok := shortStaffed[game[schedule.DATE]]; ok {
+			warning := "short-staffed"
+			if reason != "" {
+				warning += ": " + reason
+			}
+			warnings[game[schedule.GAMEID]] = append(warnings[game[schedule.GAMEID]], warning)
+		}
+	}
+	slices.SortStableFunc(swap.Games, func(a, b []string) int {
+		_, shortA := shortStaffed[a[schedule.DATE]]
+		_, shortB := shortStaffed[b[schedule.DATE]]
+		return cmp.Compare(boolRank(shortA), boolRank(shortB))
+	})
+	return warnings
+}
+
+/*
+Return 1 for true and 0 for false, for sorting.
+*/
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
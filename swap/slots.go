@@ -0,0 +1,121 @@
+package swap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Preferred slots
+
+ A team may only be able to take games on some days of the week or within
+ some hours, i.e. weekends between 9 AM and 6 PM. Potential matches outside
+ those slots are left out. Games without a time are kept when only the hours
+ are limited, as they may still fit.
+*/
+
+// Structure to hold the days and hours a team can play
+type Slots struct {
+	Days   []time.Weekday // days of the week, any day if empty
+	After  int            // earliest start in minutes after midnight, -1 for any
+	Before int            // latest start in minutes after midnight, -1 for any
+}
+
+/*
+Parse a day of the week, by its name or the first three letters.
+*/
+func parseDay(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || (len(name) >= 3 && strings.HasPrefix(full, name)) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q, expected i.e. sat or saturday", name)
+}
+
+/*
+Parse a time of day as HH:MM into minutes after midnight, -1 if empty.
+*/
+func parseClock(clock string) (int, error) {
+	if clock == "" {
+		return -1, nil
+	}
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+/*
+Parse the slots from a comma separated list of days and the earliest and
+latest start times, any of which can be empty.
+*/
+func ParseSlots(days, after, before string) (Slots, error) {
+	var slots Slots
+	for _, name := range strings.Split(days, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		day, err := parseDay(name)
+		if err != nil {
+			return slots, err
+		}
+		slots.Days = append(slots.Days, day)
+	}
+	var err error
+	if slots.After, err = parseClock(after); err != nil {
+		return slots, err
+	}
+	if slots.Before, err = parseClock(before); err != nil {
+		return slots, err
+	}
+	if slots.After >= 0 && slots.Before >= 0 && slots.Before < slots.After {
+		return slots, fmt.Errorf("latest start %s is before earliest start %s", before, after)
+	}
+	return slots, nil
+}
+
+/*
+Check if any days or hours are set.
+*/
+func (slots Slots) Limited() bool {
+	return len(slots.Days) > 0 || slots.After >= 0 || slots.Before >= 0
+}
+
+/*
+Check if a game is in the slots.
+*/
+func (slots Slots) fits(game []string) bool {
+	start, err := schedule.Start(game)
+	if err != nil {
+		// a game that can't be placed can't be ruled out either
+		return true
+	}
+	if len(slots.Days) > 0 && !slices.Contains(slots.Days, start.Weekday()) {
+		return false
+	}
+	if strings.TrimSpace(game[schedule.TIME]) == "" {
+		return true
+	}
+	minutes := start.Hour()*60 + start.Minute()
+	return (slots.After < 0 || minutes >= slots.After) && (slots.Before < 0 || minutes <= slots.Before)
+}
+
+/*
+Remove the potential matches outside the slots.
+*/
+func (swap *Swap) RemoveOutsideSlots(slots Slots) {
+	swap.removeBy("outside the preferred slots", func(game []string) string {
+		if slots.fits(game) {
+			return ""
+		}
+		return game[schedule.GAMEID]
+	})
+}
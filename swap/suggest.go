@@ -0,0 +1,116 @@
+package swap
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Game id suggestions
+
+ A mistyped game id isn't in the schedule, so the search has nothing to swap.
+ The ids in the schedule are used to say what was probably meant: the ids
+ closest to the one entered, and the form ids take when the one entered
+ doesn't look like any of them (i.e. a date or team name entered by mistake).
+*/
+
+// Most suggestions to give for a game id that isn't found
+const MAX_SUGGESTIONS = 3
+
+/*
+Return the shape of a game id with letters as A and digits as 9, i.e.
+HLU1501 is AAA9999.
+*/
+func idPattern(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r):
+			return 'A'
+		case unicode.IsDigit(r):
+			return '9'
+		}
+		return r
+	}, id)
+}
+
+/*
+Return the number of single character edits to turn a into b.
+*/
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+/*
+Return the game ids in the schedule closest to the one entered, ignoring case,
+closest first. Ids more than two edits away aren't suggested.
+*/
+func Suggest(games [][]string, gameId string) []string {
+	type suggestion struct {
+		id       string
+		distance int
+	}
+	var suggestions []suggestion
+	seen := make(map[string]bool)
+	for _, game := range games {
+		id := game[schedule.GAMEID]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if d := editDistance(strings.ToUpper(gameId), strings.ToUpper(id)); d <= 2 {
+			suggestions = append(suggestions, suggestion{id, d})
+		}
+	}
+	slices.SortStableFunc(suggestions, func(a, b suggestion) int {
+		return cmp.Or(cmp.Compare(a.distance, b.distance), cmp.Compare(a.id, b.id))
+	})
+
+	var ids []string
+	for _, s := range suggestions[:min(len(suggestions), MAX_SUGGESTIONS)] {
+		ids = append(ids, s.id)
+	}
+	return ids
+}
+
+/*
+Return the error for a game id that isn't in the schedule, suggesting the ids
+that were probably meant.
+*/
+func notFound(games [][]string, gameId string) error {
+	if ids := Suggest(games, gameId); len(ids) > 0 {
+		return fmt.Errorf("game %s not found in the schedule, did you mean %s?",
+			gameId, strings.Join(ids, " or "))
+	}
+
+	// Say what the ids look like when the one entered doesn't look like any
+	pattern := idPattern(gameId)
+	idx := slices.IndexFunc(games, func(game []string) bool {
+		return idPattern(game[schedule.GAMEID]) == pattern
+	})
+	if idx < 0 && len(games) > 0 {
+		return fmt.Errorf("game %s not found in the schedule, game ids look like %s",
+			gameId, games[0][schedule.GAMEID])
+	}
+	return fmt.Errorf("game %s not found in the schedule", gameId)
+}
@@ -0,0 +1,323 @@
+/*
+Package swap finds the games in a schedule that a game can be swapped with.
+
+A swap exchanges the dates of two games. The potential matches for a game
+are the games in a division the game's division can swap with, on a date
+neither of its teams is playing, between teams that aren't playing on the
+game's date.
+*/
+package swap
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+// Structure to hold swap information
+type Swap struct {
+	Date         string         // date of the game to swap
+	Game         []string       // schedule row for the game to swap
+	GameID       string         // game id
+	Home         string         // teams needing a swap
+	Away         string         // teams needing a swap
+	ExcludeTeams []string       // list of team already playing on swap date
+	ExcludeDates []string       // list of dates swap game teams are playing on
+	Excluded     []Exclusion    // teams, dates, arenas and games left out and why
+	Games        [][]string     // list of potentialMatches from the schedule file
+	Index        schedule.Index // all games in the schedule bucketed by date
+	Funnel       []Step         // number of games left after each filter
+}
+
+// Structure to hold the number of games left after a search filter
+type Step struct {
+	Label string `json:"label"` // i.e. "after cutoff"
+	Games int    `json:"games"`
+}
+
+/*
+Record the number of potential matches left after a filter.
+*/
+func (swap *Swap) AddStep(label string) {
+	swap.Funnel = append(swap.Funnel, Step{Label: label, Games: len(swap.Games)})
+}
+
+// Structure to hold information about divisions
+type Division struct {
+	Name       string `json:"name"`       // name of the division
+	NameRegex  string `json:"nameRegex"`  // regex for matching division
+	Swaps      string `json:"swaps"`      // description of swaps
+	SwapsRegex string `json:"swapsRegex"` // regular expression for finding swaps
+}
+
+// Division rules, the first division matching a name is used
+type Rules []Division
+
+// Contains division names and rules for swapping games, replaced by the
+// association's preset or the rules file if there is one
+var Divisions = mustPreset(DEFAULT_PRESET)
+
+/*
+Normalize a team name to uppercase with any score removed.
+*/
+func NormalizeTeam(str string) string {
+	// If scores have been added you need to cut the scores
+	// Example:  BLACKBURN STINGERS U15 B1 (1) -> BLACKBURN STINGERS U15 B1
+	before, _, _ := strings.Cut(str, " (")
+	return strings.ToUpper(before)
+}
+
+/*
+Split a comma separated list of team names into normalized names, ignoring
+blanks.
+*/
+func ParseTeams(list string) []string {
+	var names []string
+	for _, team := range strings.Split(list, ",") {
+		if team = strings.TrimSpace(team); team != "" {
+			names = append(names, NormalizeTeam(team))
+		}
+	}
+	return names
+}
+
+/*
+Normalize everything to uppercase. Check to see if the string is already in
+the list. If so then return the original list; otherwise, append the new
+string and return the updated list.
+*/
+func AddUnique(list []string, str string) []string {
+	tStr := NormalizeTeam(str)
+
+	//list[tStr] = true
+	for _, v := range list {
+		if strings.ToUpper(v) == tStr {
+			return list
+		}
+	}
+
+	list = append(list, tStr)
+	return list
+}
+
+/*
+Find the rules for a division name from the schedule. An error listing the
+known patterns is returned if none of the rules match, searching with empty
+rules would match every division.
+*/
+func (rules Rules) Find(name string) (Division, error) {
+	var patterns []string
+	for _, division := range rules {
+		re, err := CompileCached(division.NameRegex)
+		if err != nil {
+			return Division{}, fmt.Errorf("division %s: %w", division.Name, err)
+		}
+		if re.MatchString(name) {
+			return division, nil
+		}
+		patterns = append(patterns, division.NameRegex)
+	}
+	return Division{}, fmt.Errorf("no division rule matches division %q (known patterns: %s)",
+		name, strings.Join(patterns, ", "))
+}
+
+/*
+Find the division rules for a division name from the schedule, using the
+association's rules.
+*/
+func FindDivision(name string) (Division, error) {
+	return Divisions.Find(name)
+}
+
+// Compiled regular expressions, so large schedules don't recompile the same
+// division patterns for every game. The lock lets searches run at the same
+// time.
+var (
+	compiledMu  sync.Mutex
+	compiledRes = make(map[string]*regexp.Regexp)
+)
+
+/*
+Compile a regular expression, reusing it if it has been compiled before.
+Patterns come from the rules files, so an invalid one is an error rather
+than a panic.
+*/
+func CompileCached(expr string) (*regexp.Regexp, error) {
+	compiledMu.Lock()
+	defer compiledMu.Unlock()
+	if re, ok := compiledRes[expr]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	compiledRes[expr] = re
+	return re, nil
+}
+
+/*
+Set up the swap for a game from the schedule and find the rules for its
+division. The games in the swap are a copy of the whole schedule and the index
+must be built from the same schedule.
+*/
+func New(games [][]string, index schedule.Index, gameId string) (Swap, Division, error) {
+	swap, err := newSwap(games, index, gameId)
+	if err != nil {
+		return Swap{}, Division{}, err
+	}
+
+	// Select the right division by matching the regex with the division name
+	// from the game
+	division, err := FindDivision(swap.Game[schedule.DIVISION])
+	if err != nil {
+		return Swap{}, Division{}, fmt.Errorf("game %s: %w", gameId, err)
+	}
+	return swap, division, nil
+}
+
+/*
+Set up the swap for a game from the schedule, without the division rules.
+*/
+func newSwap(games [][]string, index schedule.Index, gameId string) (Swap, error) {
+	// create a debugger object
+	var debug = debuggo.Debug("newSwap")
+
+	idx := slices.IndexFunc(games, func(game []string) bool {
+		return game[schedule.GAMEID] == gameId
+	})
+	if idx < 0 {
+		return Swap{}, notFound(games, gameId)
+	}
+	game := games[idx]
+	debug("Found game %s on line %d\n", gameId, idx)
+
+	return Swap{
+		Date:   game[schedule.DATE],
+		Game:   game,
+		GameID: gameId,
+		Home:   game[schedule.HOMETEAM],
+		Away:   game[schedule.AWAYTEAM],
+		Games:  slices.Clone(games),
+		Index:  index,
+	}, nil
+}
+
+/*
+Reduce the games in the swap to the potential matches. The swap date, home
+and away teams must already be set.
+
+Games are removed when they
+  - have a score recorded
+  - occur before the cut off date
+  - are in a division that can't be swapped with
+  - are on a date when either team needing a swap is playing
+  - involve a team already playing on the swap date
+*/
+func (swap *Swap) FindCandidates(swappableRe *regexp.Regexp, cutOffDate time.Time) {
+	// create a debugger object
+	var debug = debuggo.Debug("findCandidates")
+
+	// Only build the debug output when it's enabled, joining every game is
+	// expensive for large schedules
+	trace := debuggo.IsEnabled("findCandidates")
+
+	// Delete games that
+	//  - occur in the past
+	//  - don't match the swappable divisions
+	swap.AddStep("games")
+	wrongDivision := 0
+	swap.Games = slices.DeleteFunc(swap.Games, func(game []string) bool {
+		gameDate, err := time.Parse(schedule.DATE_FORMAT, game[schedule.DATE])
+		if err != nil {
+			// probably here because the first line is a header
+			if trace {
+				debug("%s << not a game", strings.Join(game, ","))
+			}
+			return true
+		}
+		if schedule.Scored(game) {
+			// a score means the game was played, even if the date is off
+			if trace {
+				debug("%s << already played", strings.Join(game, ","))
+			}
+			return true
+		}
+		if gameDate.Before(cutOffDate) {
+			// delete any games in the past or 7 days from today
+			if trace {
+				debug("%s << before cutoff date", strings.Join(game, ","))
+			}
+			return true
+		}
+		if !swappableRe.MatchString(game[schedule.DIVISION]) {
+			// delete if can't swap with the division
+			if trace {
+				debug("%s << wrong division", strings.Join(game, ","))
+			}
+			wrongDivision++
+			return true
+		}
+		return false
+	})
+	swap.Funnel = append(swap.Funnel, Step{Label: "after cutoff", Games: len(swap.Games) + wrongDivision})
+	swap.AddStep("after division filter")
+
+	// Build lists of dates and teams to exclude from potential matches
+	// 1. dates when the teams in the swaps are playing
+	// 2. teams that are already playing on the swap date
+	excludeDates := make(map[string]bool)
+	for _, game := range swap.Games {
+		if slices.Contains(game, swap.Home) || slices.Contains(game, swap.Away) {
+			swap.ExcludeDates = append(swap.ExcludeDates, game[schedule.DATE])
+			excludeDates[game[schedule.DATE]] = true
+			if trace {
+				debug("%s << swapping team", strings.Join(game, ","))
+			}
+		}
+	}
+
+	// Get the names of all teams already playing on the day of the swap
+	// game. All these teams can be dropped as potential matches
+	excludeTeams := make(map[string]bool)
+	for _, game := range swap.Index[swap.Date] {
+		// games already played don't stop a team from playing again
+		if schedule.Scored(game) {
+			continue
+		}
+		if trace {
+			debug("%s << playing on swap date", strings.Join(game, ","))
+		}
+		swap.ExcludeTeams = AddUnique(swap.ExcludeTeams, game[schedule.HOMETEAM])
+		swap.ExcludeTeams = AddUnique(swap.ExcludeTeams, game[schedule.AWAYTEAM])
+	}
+	for _, team := range swap.ExcludeTeams {
+		excludeTeams[team] = true
+	}
+
+	// Remove any games
+	// 1. for dates where the teams needing a swap are playing
+	// 2. involving other teams playing on the day of the swap
+	swap.removeBy(swap.Home+" or "+swap.Away+" playing", func(game []string) string {
+		if excludeDates[game[schedule.DATE]] {
+			return game[schedule.DATE]
+		}
+		return ""
+	})
+	swap.AddStep("after date conflicts")
+	swap.removeBy("playing on "+swap.Date, func(game []string) string {
+		for _, team := range []string{game[schedule.HOMETEAM], game[schedule.AWAYTEAM]} {
+			if team = NormalizeTeam(team); excludeTeams[team] {
+				return team
+			}
+		}
+		return ""
+	})
+	swap.AddStep("after team conflicts")
+}
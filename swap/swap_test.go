@@ -0,0 +1,57 @@
+package swap
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+Build a schedule of n games spread over a season, with 20 teams in each
+division so the date and team filters have work to do.
+*/
+func syntheticSchedule(n int) [][]string {
+	start := time.Date(2030, 9, 1, 0, 0, 0, 0, time.UTC)
+	ages := []string{"U9", "U11", "U13", "U15", "U18"}
+	levels := []string{"A", "B", "C"}
+	games := make([][]string, 0, n)
+	for i := range n {
+		division := fmt.Sprintf("%s %s%d", ages[i%len(ages)], levels[i/len(ages)%len(levels)], i%7)
+		home, away := i%20, (i/20+1+i%20)%20
+		games = append(games, []string{
+			division,
+			fmt.Sprintf("G%06d", i),
+			start.AddDate(0, 0, i%210).Format(schedule.DATE_FORMAT),
+			fmt.Sprintf("%02d:00", 7+i%14),
+			fmt.Sprintf("ARENA %d", i%60),
+			fmt.Sprintf("%s TEAM %d", division, home),
+			fmt.Sprintf("%s TEAM %d", division, away),
+		})
+	}
+	return games
+}
+
+/*
+Search a 100,000 game schedule for the potential matches of a game, the
+way the engine does once the schedule is downloaded.
+*/
+func BenchmarkFindCandidates(b *testing.B) {
+	games := syntheticSchedule(100_000)
+	index := schedule.IndexByDate(games)
+	cutOffDate := time.Date(2030, 9, 1, 0, 0, 0, 0, time.UTC)
+	gameId := games[len(games)/2][schedule.GAMEID]
+
+	for b.Loop() {
+		swap, err := newSwap(games, index, gameId)
+		if err != nil {
+			b.Fatal(err)
+		}
+		swappableRe, err := CompileCached(`^U13 [AB]`)
+		if err != nil {
+			b.Fatal(err)
+		}
+		swap.FindCandidates(swappableRe, cutOffDate)
+	}
+}
@@ -0,0 +1,102 @@
+package swap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+ Candidate tiers
+
+ A score on its own doesn't say where to start, so the scored potential
+ matches are also put in tiers: strong matches change little for either team,
+ possible ones are worth asking about and long shots are the last resort.
+ The thresholds are a percentage of the best score the weights allow, so
+ they still fit when the weights change, i.e. with 75,50 a match scoring at
+ least 75% of the best score is strong and one scoring at least 50% is
+ possible.
+*/
+
+// Names of the tiers, best first
+const (
+	TIER_STRONG   = "strong"
+	TIER_POSSIBLE = "possible"
+	TIER_LONGSHOT = "long-shot"
+)
+
+// Structure to hold the tier thresholds, in percent of the best score
+type Tiers struct {
+	Strong   float64
+	Possible float64
+}
+
+// Thresholds used when none are given
+var DefaultTiers = Tiers{Strong: 75, Possible: 50}
+
+/*
+Parse the strong and possible thresholds from a comma separated pair of
+percentages, i.e. 75,50.
+*/
+func ParseTiers(list string) (Tiers, error) {
+	parts := strings.Split(list, ",")
+	if len(parts) != 2 {
+		return Tiers{}, fmt.Errorf("tiers %q should be the strong and possible percentages, i.e. 75,50", list)
+	}
+	var values [2]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || value < 0 || value > 100 {
+			return Tiers{}, fmt.Errorf("tier threshold %q isn't a percentage", part)
+		}
+		values[i] = value
+	}
+	if values[1] > values[0] {
+		return Tiers{}, fmt.Errorf("tiers %q: the strong threshold can't be below the possible one", list)
+	}
+	return Tiers{Strong: values[0], Possible: values[1]}, nil
+}
+
+/*
+Return the thresholds as the comma separated pair ParseTiers reads.
+*/
+func (tiers Tiers) String() string {
+	return fmt.Sprintf("%g,%g", tiers.Strong, tiers.Possible)
+}
+
+/*
+Return the best score a potential match can have with the weights.
+*/
+func (weights Weights) Best() float64 {
+	return weights.SameDay + weights.TimeSlot + weights.SameVenue + weights.CloseDate
+}
+
+/*
+Return the tier of a score.
+*/
+func (tiers Tiers) Tier(score, best float64) string {
+	switch percent := score / best * 100; {
+	case percent >= tiers.Strong:
+		return TIER_STRONG
+	case percent >= tiers.Possible:
+		return TIER_POSSIBLE
+	default:
+		return TIER_LONGSHOT
+	}
+}
+
+/*
+Return the tier of each scored potential match by game id. Nothing is
+returned when every weight is 0, there are no scores to tier.
+*/
+func (tiers Tiers) Assign(scores map[string]float64, weights Weights) map[string]string {
+	best := weights.Best()
+	if best == 0 {
+		return nil
+	}
+	assigned := make(map[string]string, len(scores))
+	for id, score := range scores {
+		assigned[id] = tiers.Tier(score, best)
+	}
+	return assigned
+}
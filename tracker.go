@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+)
+
+/*
+ Swap tracker
+
+ Information about swaps that needs to be kept between runs is stored in a
+ JSON file (tracker.json by default). Notes are attached to a swap game, or
+ to one of its potential matches, and are included in the reports. Responses
+ entered in the reports are kept so they carry forward between searches.
+*/
+
+// Structure to hold a note about a swap or potential match
+type note_t struct {
+	Date time.Time `json:"date"` // when the note was added
+	Text string    `json:"text"`
+}
+
+// Structure to hold everything tracked between runs
+type tracker_t struct {
+	// Notes by swap game id, then by potential match game id. Notes about
+	// the swap itself use an empty match id.
+	Notes map[string]map[string][]note_t `json:"notes,omitempty"`
+
+	// Responses from the potential matches (i.e. Contacted, Declined) by
+	// swap game id, then by potential match game id
+	Responses map[string]map[string]string `json:"responses,omitempty"`
+
+	// When each potential match first replied, by swap game id, then by
+	// potential match game id
+	Replies map[string]map[string]time.Time `json:"replies,omitempty"`
+
+	// Refusals recorded with the decline sub-command
+	Declines []decline_t `json:"declines,omitempty"`
+
+	// Potential matches hidden until a date with the snooze sub-command
+	Snoozes []snooze_t `json:"snoozes,omitempty"`
+
+	// Dates the team is short a goalie or key players, with the reason
+	ShortStaffed map[string]string `json:"shortStaffed,omitempty"`
+
+	// Dates the team can't play at all (i.e. a tournament), with the reason
+	Blackouts map[string]string `json:"blackouts,omitempty"`
+
+	// When each swap game was first searched for, by swap game id
+	Searches map[string]time.Time `json:"searches,omitempty"`
+
+	// Accepted potential match by swap game id
+	Accepted map[string]string `json:"accepted,omitempty"`
+
+	// Potential matches in the last report, by swap game id
+	Proposed map[string][]string `json:"proposed,omitempty"`
+}
+
+// Structure to hold a snoozed potential match
+type snooze_t struct {
+	Game  string `json:"game,omitempty"` // swap game id, empty if snoozed for all swaps
+	Match string `json:"match"`          // potential match game id
+	Until string `json:"until"`          // date the match is shown again
+}
+
+// Reason codes for declines
+var declineReasons = []string{"tournament", "conflict", "ice", "travel", "players", "other"}
+
+// Structure to hold a candidate's refusal of a swap
+type decline_t struct {
+	Date   time.Time `json:"date"`           // when the decline was recorded
+	Game   string    `json:"game,omitempty"` // swap game id, empty if declined for all swaps
+	Match  string    `json:"match"`          // potential match game id
+	Reason string    `json:"reason"`         // one of declineReasons
+}
+
+/*
+Load the tracker from file. An empty tracker is returned if the file doesn't
+exist yet.
+*/
+func loadTracker(path string) (*tracker_t, error) {
+	tracker := &tracker_t{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tracker, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, tracker); err != nil {
+		return nil, err
+	}
+	return tracker, nil
+}
+
+/*
+Save the tracker to file.
+*/
+func (tracker *tracker_t) save(path string) error {
+	data, err := json.MarshalIndent(tracker, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+/*
+Change the tracker on disk. The file is locked while the latest copy is
+loaded, changed and saved, so changes made by another run at the same time
+aren't lost. Returns the tracker as saved.
+*/
+func updateTracker(path string, change func(tracker *tracker_t)) (*tracker_t, error) {
+	var tracker *tracker_t
+	err := whileWriting(func() error {
+		unlock, err := lockFile(path)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if tracker, err = loadTracker(path); err != nil {
+			return err
+		}
+		change(tracker)
+		return tracker.save(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tracker, nil
+}
+
+/*
+Add a note to a swap game, or to one of its potential matches.
+*/
+func (tracker *tracker_t) addNote(gameId, matchId, text string) {
+	if tracker.Notes == nil {
+		tracker.Notes = make(map[string]map[string][]note_t)
+	}
+	if tracker.Notes[gameId] == nil {
+		tracker.Notes[gameId] = make(map[string][]note_t)
+	}
+	tracker.Notes[gameId][matchId] = append(tracker.Notes[gameId][matchId],
+		note_t{Date: time.Now(), Text: text})
+}
+
+/*
+Return true if a response is a reply from the match. A match marked
+Contacted hasn't replied yet.
+*/
+func isReply(response string) bool {
+	return response != "" && !strings.EqualFold(response, "contacted")
+}
+
+/*
+Record the responses from the potential matches for a swap game, replacing
+any earlier response from the same match. The first reply from each match is
+timed so the teams' time to reply is known.
+*/
+func (tracker *tracker_t) setResponses(gameId string, responses map[string]string) {
+	if tracker.Responses == nil {
+		tracker.Responses = make(map[string]map[string]string)
+	}
+	if tracker.Responses[gameId] == nil {
+		tracker.Responses[gameId] = make(map[string]string)
+	}
+	maps.Copy(tracker.Responses[gameId], responses)
+
+	for matchId, response := range responses {
+		if !isReply(response) {
+			continue
+		}
+		if tracker.Replies == nil {
+			tracker.Replies = make(map[string]map[string]time.Time)
+		}
+		if tracker.Replies[gameId] == nil {
+			tracker.Replies[gameId] = make(map[string]time.Time)
+		}
+		if _, ok := tracker.Replies[gameId][matchId]; !ok {
+			tracker.Replies[gameId][matchId] = time.Now()
+		}
+	}
+}
+
+/*
+Record a decline. A decline for a swap game is also recorded as the response
+from the match, so the match's teams are left out of searches for that game.
+*/
+func (tracker *tracker_t) addDecline(gameId, matchId, reason string) {
+	tracker.Declines = append(tracker.Declines, decline_t{
+		Date:   time.Now(),
+		Game:   gameId,
+		Match:  matchId,
+		Reason: reason,
+	})
+	if gameId != "" {
+		tracker.setResponses(gameId, map[string]string{matchId: "Declined"})
+	}
+}
+
+/*
+Return the ids of the games declined for all swaps.
+*/
+func (tracker *tracker_t) declinedGames() []string {
+	var ids []string
+	for _, decline := range tracker.Declines {
+		if decline.Game == "" && !slices.Contains(ids, decline.Match) {
+			ids = append(ids, decline.Match)
+		}
+	}
+	return ids
+}
+
+/*
+Snooze a potential match until a date, replacing any earlier snooze.
+*/
+func (tracker *tracker_t) addSnooze(gameId, matchId, until string) {
+	tracker.Snoozes = slices.DeleteFunc(tracker.Snoozes, func(snooze snooze_t) bool {
+		return snooze.Game == gameId && snooze.Match == matchId
+	})
+	tracker.Snoozes = append(tracker.Snoozes, snooze_t{Game: gameId, Match: matchId, Until: until})
+}
+
+/*
+Return the ids of the potential matches snoozed for the swap game on the
+date. Snoozes end on their until date.
+*/
+func (tracker *tracker_t) snoozed(gameId, today string) []string {
+	var ids []string
+	for _, snooze := range tracker.Snoozes {
+		if (snooze.Game == "" || snooze.Game == gameId) && today < snooze.Until {
+			ids = append(ids, snooze.Match)
+		}
+	}
+	return ids
+}
+
+/*
+Tag a date as short-staffed.
+*/
+func (tracker *tracker_t) addShortStaffed(date, reason string) {
+	if tracker.ShortStaffed == nil {
+		tracker.ShortStaffed = make(map[string]string)
+	}
+	tracker.ShortStaffed[date] = reason
+}
+
+/*
+Black out a date, or clear it if remove is set.
+*/
+func (tracker *tracker_t) setBlackout(date, reason string, remove bool) {
+	if remove {
+		delete(tracker.Blackouts, date)
+		return
+	}
+	if tracker.Blackouts == nil {
+		tracker.Blackouts = make(map[string]string)
+	}
+	tracker.Blackouts[date] = reason
+}
+
+/*
+Record the first search for a swap game. Returns true if it is the first.
+*/
+func (tracker *tracker_t) addSearch(gameId string) bool {
+	if _, ok := tracker.Searches[gameId]; ok {
+		return false
+	}
+	if tracker.Searches == nil {
+		tracker.Searches = make(map[string]time.Time)
+	}
+	tracker.Searches[gameId] = time.Now()
+	return true
+}
+
+/*
+Record the potential match accepted for a swap game.
+*/
+func (tracker *tracker_t) accept(gameId, matchId string) {
+	if tracker.Accepted == nil {
+		tracker.Accepted = make(map[string]string)
+	}
+	tracker.Accepted[gameId] = matchId
+}
+
+/*
+Format the notes on one line, oldest first, for reports.
+*/
+func formatNotes(notes []note_t) string {
+	var lines []string
+	for _, note := range notes {
+		lines = append(lines, note.Date.Format(schedule.DATE_FORMAT)+" "+note.Text)
+	}
+	return strings.Join(lines, " | ")
+}
@@ -0,0 +1,101 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"syscall/js"
+	"time"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ WebAssembly build
+
+ Runs the swap search entirely in the browser against a schedule CSV the user
+ uploads, so no schedule or contact data leaves their computer. Build with:
+
+   GOOS=js GOARCH=wasm go build -o go-scheduler.wasm .
+
+ and load it with the wasm_exec.js support file shipped with Go. The build
+ registers a global JavaScript function:
+
+   findSwaps(scheduleCSV, gameId, cutOffDays) -> {division, swaps, header, matches, error}
+
+ scheduleCSV is the text of a schedule in the same format as schedule.csv and
+ matches is an array of the potential match rows.
+*/
+
+/*
+Search the schedule for the potential matches for a game.
+*/
+func findSwaps(scheduleCSV, gameId string, cutOffDays int) (swap.Division, [][]string, error) {
+	games, err := csv.NewReader(strings.NewReader(scheduleCSV)).ReadAll()
+	if err != nil {
+		return swap.Division{}, nil, err
+	}
+
+	s, division, err := swap.New(games, schedule.IndexByDate(games), gameId)
+	if err != nil {
+		return division, nil, err
+	}
+
+	cutOffDate := time.Now().AddDate(0, 0, cutOffDays)
+	gameDate, err := time.Parse(schedule.DATE_FORMAT, s.Date)
+	if err != nil {
+		return division, nil, err
+	}
+	if gameDate.Before(cutOffDate) {
+		return division, nil, fmt.Errorf("game date is before cut off date of %s",
+			cutOffDate.Format(schedule.DATE_FORMAT))
+	}
+
+	swappableRe, err := swap.CompileCached(division.SwapsRegex)
+	if err != nil {
+		return division, nil, err
+	}
+	s.FindCandidates(swappableRe, cutOffDate)
+	return division, s.Games, nil
+}
+
+/*
+JavaScript wrapper for findSwaps.
+*/
+func findSwapsJS(this js.Value, args []js.Value) any {
+	if len(args) != 3 {
+		return map[string]any{"error": "usage: findSwaps(scheduleCSV, gameId, cutOffDays)"}
+	}
+
+	division, games, err := findSwaps(args[0].String(), args[1].String(), args[2].Int())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+
+	// Values returned to JavaScript must be built from basic types
+	matches := make([]any, len(games))
+	for i, game := range games {
+		row := make([]any, len(game))
+		for j, field := range game {
+			row[j] = field
+		}
+		matches[i] = row
+	}
+	return map[string]any{
+		"division": division.Name,
+		"swaps":    division.Swaps,
+		"header": []any{"Division", "Game ID", "Date", "Time", "Arena",
+			"Home Team", "Away Team"},
+		"matches": matches,
+	}
+}
+
+func main() {
+	js.Global().Set("findSwaps", js.FuncOf(findSwapsJS))
+
+	// Keep running so the function stays available to the page
+	select {}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/leonard0022/go-scheduler/schedule"
+	"github.com/leonard0022/go-scheduler/swap"
+)
+
+/*
+ Excel output
+
+ The report is written as an Excel workbook with the potential matches on the
+ first sheet, the contacts of the teams on the second and what the search
+ excluded and was searched with on the sheets after. Each sheet has a bold
+ header row which stays in view when scrolling, and the matches have an
+ autofilter.
+
+ The matches sheet doubles as a tracking sheet. Each match is shaded by its
+ tier, green for strong, yellow for possible and red for a long shot, and
+ matches at the same arena as the game being swapped are in bold. The
+ Status column has a dropdown of Contacted, Declined and Accepted, filled in
+ from the responses so far.
+
+ The workbook is written directly as SpreadsheetML in a zip file. Only the
+ parts Excel, LibreOffice and Google Sheets need are written and all cells are
+ inline strings.
+*/
+
+// Excel output
+type xlsxOutput struct{}
+
+func (xlsxOutput) Ext() string { return ".xlsx" }
+
+// Choices in the status dropdown of the matches
+var xlsxStatuses = []string{"Contacted", "Declined", "Accepted"}
+
+// Structure to hold a worksheet of the workbook
+type xlsxSheet struct {
+	name     string
+	rows     [][]string       // the first row is the header
+	filter   bool             // add an autofilter to the header
+	styles   []int            // style of each row after the header, 0 for the default
+	dropdown map[int][]string // choices for the cells below the header by column
+}
+
+func (xlsxOutput) Write(w io.Writer, report report_t) error {
+	header, rows := report.table()
+	candidates := xlsxSheet{name: "Candidates", filter: true,
+		dropdown: map[int][]string{len(header): xlsxStatuses}}
+	candidates.rows = append(candidates.rows, append(slices.Clone(header), "Status"))
+	for i, g := range report.swap.Games {
+		status := ""
+		n := slices.IndexFunc(xlsxStatuses, func(s string) bool {
+			return strings.EqualFold(s, report.responses[g[schedule.GAMEID]])
+		})
+		if n >= 0 {
+			status = xlsxStatuses[n]
+		}
+		candidates.rows = append(candidates.rows, append(rows[i], status))
+		candidates.styles = append(candidates.styles, report.xlsxStyle(g))
+	}
+	sheets := []xlsxSheet{candidates}
+	// The contacts aren't shared when they're redacted
+	if !report.redact {
+		sheets = append(sheets, xlsxSheet{name: "Contacts", rows: report.contactRows()})
+	}
+	sheets = append(sheets,
+		xlsxSheet{name: "Excluded", rows: report.excludedRows()},
+		xlsxSheet{name: "Search Parameters", rows: report.parameterRows()})
+	if report.summary {
+		sheets = append(sheets, xlsxSheet{name: "Summary", rows: report.summaryRows()})
+	}
+	return writeXLSX(w, sheets)
+}
+
+/*
+Return the style of a potential match's row, shaded by its tier and in bold
+if it's at the same arena as the game being swapped.
+*/
+func (report report_t) xlsxStyle(game []string) int {
+	tier := slices.Index([]string{swap.TIER_STRONG, swap.TIER_POSSIBLE, swap.TIER_LONGSHOT},
+		report.tiers[game[schedule.GAMEID]]) + 1
+	sameVenue := 0
+	if strings.EqualFold(strings.TrimSpace(game[schedule.VENUE]), strings.TrimSpace(report.swap.Game[schedule.VENUE])) {
+		sameVenue = 1
+	}
+	return 2 + tier*2 + sameVenue
+}
+
+/*
+Return the contacts of the teams in the swap and the potential matches, one
+row for each team.
+*/
+func (report report_t) contactRows() [][]string {
+	s := report.swap
+	teams := []string{s.Home, s.Away}
+	for _, g := range s.Games {
+		teams = append(teams, g[schedule.HOMETEAM], g[schedule.AWAYTEAM])
+	}
+
+	rows := [][]string{{"Team", "Coach", "Coach Email", "Manager", "Manager Email", "Other Emails"}}
+	var seen []string
+	for _, team := range teams {
+		if slices.Contains(seen, team) {
+			continue
+		}
+		seen = append(seen, team)
+		c := report.contacts[team]
+		rows = append(rows, []string{team, c.Coach, c.CoachEmail, c.Manager,
+			c.ManagerEmail, strings.Join(c.Others, ";")})
+	}
+	return rows
+}
+
+/*
+Return why games were left out of the potential matches: each team, date,
+arena or game a filter excluded, why and how many matches it removed.
+*/
+func (report report_t) excludedRows() [][]string {
+	rows := [][]string{{"Excluded", "Reason", "Matches"}}
+	for _, e := range report.swap.Excluded {
+		rows = append(rows, []string{e.What, e.Reason, fmt.Sprint(e.Games)})
+	}
+	return rows
+}
+
+/*
+Return the game searched for, the divisions searched and how many games were
+left after each filter.
+*/
+func (report report_t) parameterRows() [][]string {
+	s := report.swap
+	rows := [][]string{
+		{"Parameter", "Value"},
+		{"Game ID", s.GameID},
+		{"Date", s.Date},
+		{"Home Team", s.Home},
+		{"Away Team", s.Away},
+		{"Division", report.division.Name},
+		{"Swappable divisions", report.division.Swaps},
+	}
+	for _, step := range s.Funnel {
+		rows = append(rows, []string{"Games " + step.Label, fmt.Sprint(step.Games)})
+	}
+	return rows
+}
+
+/*
+Return the column letters for a zero based column number, i.e. 0 is A and 26
+is AA.
+*/
+func xlsxColumn(n int) string {
+	column := ""
+	for n++; n > 0; n = (n - 1) / 26 {
+		column = string(rune('A'+(n-1)%26)) + column
+	}
+	return column
+}
+
+/*
+Escape text for use in the XML of the workbook.
+*/
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+/*
+Write the worksheet XML for a sheet. The header row uses the bold style and
+is frozen.
+*/
+func (sheet xlsxSheet) xml() string {
+	width := 0
+	for _, row := range sheet.rows {
+		width = max(width, len(row))
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	b.WriteString(`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+	b.WriteString(`</sheetView></sheetViews>`)
+	if width > 0 {
+		// Size the columns to fit their longest value, within reason
+		b.WriteString(`<cols>`)
+		for c := range width {
+			chars := 8
+			for _, row := range sheet.rows {
+				if c < len(row) {
+					chars = max(chars, len(row[c]))
+				}
+			}
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, c+1, c+1, min(chars+2, 60))
+		}
+		b.WriteString(`</cols>`)
+	}
+	b.WriteString(`<sheetData>`)
+	for r, row := range sheet.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, value := range row {
+			style := ""
+			if r == 0 {
+				style = ` s="1"`
+			} else if r <= len(sheet.styles) && sheet.styles[r-1] > 0 {
+				style = fmt.Sprintf(` s="%d"`, sheet.styles[r-1])
+			}
+			fmt.Fprintf(&b, `<c r="%s%d" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`,
+				xlsxColumn(c), r+1, style, xmlEscape(value))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData>`)
+	if sheet.filter && width > 0 {
+		fmt.Fprintf(&b, `<autoFilter ref="A1:%s%d"/>`, xlsxColumn(width-1), len(sheet.rows))
+	}
+	if len(sheet.dropdown) > 0 && len(sheet.rows) > 1 {
+		fmt.Fprintf(&b, `<dataValidations count="%d">`, len(sheet.dropdown))
+		for _, c := range slices.Sorted(maps.Keys(sheet.dropdown)) {
+			fmt.Fprintf(&b, `<dataValidation type="list" allowBlank="1" showErrorMessage="1" sqref="%s2:%s%d">`+
+				`<formula1>"%s"</formula1></dataValidation>`, xlsxColumn(c), xlsxColumn(c), len(sheet.rows),
+				xmlEscape(strings.Join(sheet.dropdown[c], ",")))
+		}
+		b.WriteString(`</dataValidations>`)
+	}
+	b.WriteString(`</worksheet>`)
+	return b.String()
+}
+
+// Styles of the workbook, style 1 is the bold header with a grey fill. The
+// match styles follow in pairs, plain then bold, for no tier, strong,
+// possible and long-shot.
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="6"><fill><patternFill patternType="none"/></fill>` +
+	`<fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFD9D9D9"/></patternFill></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFC6EFCE"/></patternFill></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFFFEB9C"/></patternFill></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFFFC7CE"/></patternFill></fill></fills>` +
+	`<borders count="1"><border/></borders>` +
+	`<cellStyleXfs count="1"><xf/></cellStyleXfs>` +
+	`<cellXfs count="10"><xf/><xf fontId="1" fillId="2" applyFont="1" applyFill="1"/>` +
+	`<xf/><xf fontId="1" applyFont="1"/>` +
+	`<xf fillId="3" applyFill="1"/><xf fontId="1" fillId="3" applyFont="1" applyFill="1"/>` +
+	`<xf fillId="4" applyFill="1"/><xf fontId="1" fillId="4" applyFont="1" applyFill="1"/>` +
+	`<xf fillId="5" applyFill="1"/><xf fontId="1" fillId="5" applyFont="1" applyFill="1"/></cellXfs>` +
+	`</styleSheet>`
+
+/*
+Write the sheets as an Excel workbook.
+*/
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	var contentTypes, workbook, workbookRels strings.Builder
+
+	contentTypes.WriteString(xml.Header)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+
+	workbook.WriteString(xml.Header)
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+
+	workbookRels.WriteString(xml.Header)
+	workbookRels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	// Excel needs a hidden name for each sheet's autofilter
+	var names []string
+	for i, sheet := range sheets {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" `+
+			`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.name), n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" `+
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" `+
+			`Target="worksheets/sheet%d.xml"/>`, n, n)
+		if sheet.filter && len(sheet.rows) > 0 {
+			names = append(names, fmt.Sprintf(`<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">'%s'!$A$1:$%s$%d</definedName>`,
+				i, strings.ReplaceAll(xmlEscape(sheet.name), "'", "''"), xlsxColumn(len(sheet.rows[0])-1), len(sheet.rows)))
+		}
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets>`)
+	if len(names) > 0 {
+		workbook.WriteString(`<definedNames>` + strings.Join(names, "") + `</definedNames>`)
+	}
+	workbook.WriteString(`</workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" `+
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" `+
+		`Target="styles.xml"/></Relationships>`, len(sheets)+1)
+
+	parts := []struct{ name, data string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" ` +
+			`Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xml.Header + xlsxStyles},
+	}
+	for i, sheet := range sheets {
+		parts = append(parts, struct{ name, data string }{
+			fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.xml()})
+	}
+
+	archive := zip.NewWriter(w)
+	for _, part := range parts {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}